@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"go/ast"
 	"go/format"
 	"go/parser"
 	"go/token"
@@ -17,6 +18,8 @@ import (
 
 type opts struct {
 	srcPath string
+	client bool
+	validators bool
 	build bool
 	binName string
 	watch bool
@@ -24,7 +27,7 @@ type opts struct {
 
 func main() {
 	app := cli.App("gowasm", "Generate wasm libraries from go source")
-	app.Spec = "SRC [-e] [-b BIN] [-w]"
+	app.Spec = "SRC [-e] [--batch] [--handle...] [--stats] [--client] [--validators] [--js-package] [--js-ident] [--async] [--trace] [--strict-arity] [--recover] [--envelope] [--named-resolvers] [--lazy-slices] [-b BIN] [-w]"
 
 	var (
 		// cmd options
@@ -32,9 +35,23 @@ func main() {
 		build       = app.BoolOpt("b build", false, "Build a wasm binary after code generation")
 		binName       = app.StringArg("BIN", "", "The name of the built wasm binary (relative to src)")
 		watch       = app.BoolOpt("w watch", false, "Regenerate when a source file is changed")
+		client = app.BoolOpt("client", false, "Also generate a ClientWasm interface with a direct-call implementation")
+		validators = app.BoolOpt("validators", false, "Also generate a js script checking argument types before calling into wasm")
 
 		// generator options
 		exportWrappers = app.BoolOpt("e export", false, "Export wasm wrappers")
+		batchCalls = app.BoolOpt("batch", false, "Expose a __batch function that dispatches several calls at once")
+		lazySlices = app.BoolOpt("lazy-slices", false, "Return slices as js Proxies reading each element when it's first accessed")
+		handleTypes = app.StringsOpt("handle", nil, "A type resolved from js handle ints through a registered handle table")
+		resolverStats = app.BoolOpt("stats", false, "Count resolver runs, reported to js by __resolverStats")
+		async = app.BoolOpt("async", false, "Return a Promise from each function, running its body on a new goroutine")
+		traceCalls = app.BoolOpt("trace", false, "Run the OnCallStartWasm and OnCallEndWasm hooks around each call")
+		strictArity = app.BoolOpt("strict-arity", false, "Throw when a function is called with fewer arguments than it has parameters")
+		recoverPanics = app.BoolOpt("recover", false, "Throw panics, including those of resolvers, to js as catchable Errors rather than ending the program")
+		envelopeReturns = app.BoolOpt("envelope", false, "Return {ok, value, error} objects from each function instead of throwing")
+		namedResolvers = app.BoolOpt("named-resolvers", false, "Resolve named types through functions generated once rather than inline")
+		jsPackage = app.StringOpt("js-package", "syscall/js", "The package generated code uses in place of syscall/js")
+		jsPackageIdent = app.StringOpt("js-ident", "", "The identifier generated code imports the js package under, e.g. wjs")
 
 	)
 	
@@ -42,12 +59,26 @@ func main() {
 		err := execute(
 			&opts{
 				srcPath: *srcPath,
+				client: *client,
+				validators: *validators,
 				build: *build,
 				binName: *binName,
 				watch: *watch,
 			},
 			&generator.Config{
 				ExportWrappers: *exportWrappers,
+				BatchCalls: *batchCalls,
+				LazySlices: *lazySlices,
+				HandleTypes: *handleTypes,
+				ResolverStats: *resolverStats,
+				JSPackage: *jsPackage,
+				JSPackageIdent: *jsPackageIdent,
+				Async: *async,
+				TraceCalls: *traceCalls,
+				StrictArity: *strictArity,
+				RecoverPanics: *recoverPanics,
+				EnvelopeReturns: *envelopeReturns,
+				EmitNamedResolvers: *namedResolvers,
 			},
 		)
 		if err != nil {
@@ -60,7 +91,7 @@ func main() {
 }
 
 func execute(cliOpts *opts, genConfig *generator.Config) error {
-	err := gowasm(cliOpts.srcPath, genConfig)
+	err := gowasm(cliOpts.srcPath, cliOpts.client, cliOpts.validators, genConfig)
 	if err != nil {
 		return err
 	}
@@ -82,9 +113,9 @@ func execute(cliOpts *opts, genConfig *generator.Config) error {
 	return nil
 }
 
-func gowasm(srcPath string, genConfig *generator.Config) error {
+func gowasm(srcPath string, client bool, validators bool, genConfig *generator.Config) error {
 	fset := token.NewFileSet()
-	pkgs, err := parser.ParseDir(fset, srcPath, nil, 0)
+	pkgs, err := parser.ParseDir(fset, srcPath, nil, parser.ParseComments)
 	if err != nil {
 		return fmt.Errorf("Error parsing dir: %v", err)
 	}
@@ -101,22 +132,48 @@ func gowasm(srcPath string, genConfig *generator.Config) error {
 		return fmt.Errorf("Error generating go wasm wrappers: %v", err)
 	}
 
-	outPath := filepath.Join(srcPath, "wasm-wrappers.go")
-	outFile, err := os.Create(outPath)
+	err = writeFile(fset, filepath.Join(srcPath, "wasm-wrappers.go"), wrapperFile)
 	if err != nil {
-		fmt.Printf("Error creating wrapper file: %v\n", err)
-		cli.Exit(1)
+		return fmt.Errorf("Error writing wrapper file: %v", err)
 	}
-	defer outFile.Close()
 
-	err = format.Node(outFile, fset, wrapperFile)
-	if err != nil {
-		return fmt.Errorf("Error formatting wrapper file: %v", err)
+	if client {
+		clientFile, err := generator.GenerateClientFile(pkg, genConfig)
+		if err != nil {
+			return fmt.Errorf("Error generating go client: %v", err)
+		}
+
+		err = writeFile(fset, filepath.Join(srcPath, "wasm-client.go"), clientFile)
+		if err != nil {
+			return fmt.Errorf("Error writing client file: %v", err)
+		}
+	}
+
+	if validators {
+		validatorFile, err := generator.GenerateValidatorFile(pkg, genConfig)
+		if err != nil {
+			return fmt.Errorf("Error generating js validators: %v", err)
+		}
+
+		err = os.WriteFile(filepath.Join(srcPath, "wasm-validators.js"), validatorFile, 0644)
+		if err != nil {
+			return fmt.Errorf("Error writing validator file: %v", err)
+		}
 	}
 
 	return nil
 }
 
+func writeFile(fset *token.FileSet, outPath string, file *ast.File) error {
+	outFile, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+
+	return format.Node(outFile, fset, file)
+}
+
 func build(srcPath, binName string) error {
 	buildCmd := exec.Command("go", "build", "-o", filepath.Join(srcPath, binName), srcPath)
 	buildCmd.Env = append(os.Environ(), "GOOS=js", "GOARCH=wasm")
@@ -137,7 +194,7 @@ func watch(cliOpts *opts, genConfig *generator.Config) error {
 		for {
 			select {
 			case event := <-w.Event:	
-				if filepath.Base(event.Path) == "wasm-wrappers.go" {
+				if base := filepath.Base(event.Path); base == "wasm-wrappers.go" || base == "wasm-client.go" || base == "wasm-validators.js" {
 					continue
 				}
 
@@ -0,0 +1,130 @@
+package generator
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"sort"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// returns a file declaring a ClientWasm interface with a method for each of the exported functions in the pkg
+// and a LocalClientWasm implementation that calls them directly,
+// so code depending on the bindings can be tested without syscall/js
+func GenerateClientFile(pkg *ast.Package, config *Config) (*ast.File, error) {
+	if pkg == nil {
+		return nil, fmt.Errorf("Pkg can't be nil")
+	}
+
+	gen := newGenerator(pkg, config)
+	funcs := make([]*ast.FuncDecl, 0)
+	for _, file := range pkg.Files {
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && isExposed(fn) {
+				funcs = append(funcs, fn)
+			}
+		}
+	}
+	sort.Slice(funcs, func(i, j int) bool {
+		return funcs[i].Name.Name < funcs[j].Name.Name
+	})
+
+	methods := make([]*ast.Field, 0, len(funcs))
+	decls := make([]ast.Decl, 0, len(funcs)+2)
+	for _, fn := range funcs {
+		err := gen.addTypeImports(fn.Type)
+		if err != nil {
+			return nil, fmt.Errorf("Error adding imports for function \"%s\": %v", fn.Name.Name, err)
+		}
+
+		methodType := clientMethodType(fn.Type)
+		methods = append(methods, &ast.Field{
+			Names: []*ast.Ident{{Name: fn.Name.Name}},
+			Type:  methodType,
+		})
+		decls = append(decls, localClientMethod(fn, methodType))
+	}
+
+	decls = append([]ast.Decl{
+		&ast.GenDecl{
+			Tok: token.TYPE,
+			Specs: []ast.Spec{
+				&ast.TypeSpec{
+					Name: &ast.Ident{Name: "ClientWasm"},
+					Type: &ast.InterfaceType{
+						Methods: &ast.FieldList{List: methods},
+					},
+				},
+			},
+		},
+		&ast.GenDecl{
+			Tok: token.TYPE,
+			Specs: []ast.Spec{
+				&ast.TypeSpec{
+					Name: &ast.Ident{Name: "LocalClientWasm"},
+					Type: &ast.StructType{
+						Fields: &ast.FieldList{},
+					},
+				},
+			},
+		},
+	}, decls...)
+
+	clientFile := &ast.File{
+		Name:  &ast.Ident{Name: pkg.Name},
+		Decls: decls,
+	}
+
+	fset := token.NewFileSet()
+	for path := range gen.imports {
+		astutil.AddImport(fset, clientFile, path)
+	}
+
+	return clientFile, nil
+}
+
+// returns a copy of the given signature with every parameter named (see namedParams)
+func clientMethodType(fnType *ast.FuncType) *ast.FuncType {
+	return &ast.FuncType{
+		Params:  namedParams(fnType.Params),
+		Results: fnType.Results,
+	}
+}
+
+// returns a LocalClientWasm method forwarding its arguments to fn
+//
+// generated method:
+// 	func (LocalClientWasm) Example(a int, b ...string) string {
+// 		return Example(a, b...)
+// 	}
+func localClientMethod(fn *ast.FuncDecl, methodType *ast.FuncType) *ast.FuncDecl {
+	call := &ast.CallExpr{Fun: &ast.Ident{Name: fn.Name.Name}}
+	for _, param := range methodType.Params.List {
+		for _, name := range param.Names {
+			call.Args = append(call.Args, name)
+		}
+
+		if _, ok := param.Type.(*ast.Ellipsis); ok {
+			call.Ellipsis = 1
+		}
+	}
+
+	var body ast.Stmt = &ast.ReturnStmt{Results: []ast.Expr{call}}
+	if fn.Type.Results.NumFields() == 0 {
+		body = &ast.ExprStmt{X: call}
+	}
+
+	return &ast.FuncDecl{
+		Recv: &ast.FieldList{
+			List: []*ast.Field{
+				{Type: &ast.Ident{Name: "LocalClientWasm"}},
+			},
+		},
+		Name: &ast.Ident{Name: fn.Name.Name},
+		Type: methodType,
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{body},
+		},
+	}
+}
@@ -0,0 +1,72 @@
+package generator
+
+import (
+	"go/ast"
+	"strings"
+)
+
+// a `//wasm:name=value args...` comment in the doc of a function declaration.
+// args usually name the parameters the directive applies to
+type directive struct {
+	name  string
+	value string
+	args  []string
+}
+
+// returns the wasm directives in the given doc comment,
+// the package must be parsed with parser.ParseComments for doc comments to be available
+func parseDirectives(doc *ast.CommentGroup) []*directive {
+	if doc == nil {
+		return nil
+	}
+
+	directives := make([]*directive, 0)
+	for _, comment := range doc.List {
+		if !strings.HasPrefix(comment.Text, "//wasm:") {
+			continue
+		}
+
+		fields := strings.Fields(strings.TrimPrefix(comment.Text, "//wasm:"))
+		if len(fields) == 0 {
+			continue
+		}
+
+		name, value, _ := strings.Cut(fields[0], "=")
+		directives = append(directives, &directive{
+			name:  name,
+			value: value,
+			args:  fields[1:],
+		})
+	}
+
+	return directives
+}
+
+// returns the named directive of the function being wrapped, or nil if it has none
+func (gen *generator) getDirective(name string) *directive {
+	for _, d := range gen.directives {
+		if d.name == name {
+			return d
+		}
+	}
+
+	return nil
+}
+
+// returns the named directive of the function being wrapped that lists param in its args,
+// or nil if it has none
+func (gen *generator) getParamDirective(name string, param string) *directive {
+	for _, d := range gen.directives {
+		if d.name != name {
+			continue
+		}
+
+		for _, arg := range d.args {
+			if arg == param {
+				return d
+			}
+		}
+	}
+
+	return nil
+}
@@ -7,8 +7,32 @@ type generator struct {
 	pkg *ast.Package
 	typeAliases map[string]ast.Expr
 	aliasResolvers map[string]*ast.FuncDecl
+	// the declarations of each TLazyWasm type and its field getters, keyed by type
+	lazyTypes map[string][]ast.Decl
 	funcSignatures map[string]*ast.FuncType
 	funcWrappers map[string]*ast.FuncDecl
+	imports map[string]bool
+	helpers map[string]bool
+	statKinds map[string]int
+	// directives of the function being wrapped
+	directives []*directive
+	// the this value of the wrapper being generated, nil outside wrappers
+	this ast.Expr
+	// named types being resolved, reached again they're resolved through a generated function
+	resolving map[string]bool
+	// the named struct type whose fields are being resolved, empty for anonymous structs
+	structName string
+	// nesting of the structs, arrays and pointers enclosing the value being resolved
+	depth int
+	// the depth parameter of the named type resolver function being generated, which depth is relative to.
+	// nil outside those functions, or without MaxDepth
+	depthParam ast.Expr
+	// the //wasm:validate func checking each element of the slice being resolved
+	eltValidator ast.Expr
+	// the name of the function whose struct result is encoded into a reused js object, set by //wasm:reuse
+	reuseObject string
+	// named types whose values are being encoded, recursive types can't be encoded inline
+	encoding map[string]bool
 }
 
 func newGenerator(pkg *ast.Package, config *Config) *generator {
@@ -21,14 +45,105 @@ func newGenerator(pkg *ast.Package, config *Config) *generator {
 		pkg: pkg,
 		typeAliases: make(map[string]ast.Expr),
 		aliasResolvers: make(map[string]*ast.FuncDecl),
+		lazyTypes: make(map[string][]ast.Decl),
+		resolving: make(map[string]bool),
 		funcSignatures: make(map[string]*ast.FuncType),
 		funcWrappers: make(map[string]*ast.FuncDecl),
+		imports: make(map[string]bool),
+		helpers: make(map[string]bool),
+		statKinds: make(map[string]int),
+		encoding: make(map[string]bool),
 	}
 }
 
 type Config struct {
 	ExportWrappers bool
 	AliasResolvers bool
+	// expose a __batch function that dispatches an array of {fn, args} calls
+	BatchCalls bool
+	// return slices as js Proxies of arrays that read each element from the go slice the first time it's accessed,
+	// rather than converting every element up front. elements hold the slice's values as of then
+	LazySlices bool
+	// types resolved from js handle ints through the tables registered with RegisterHandleTypeWasm.
+	// lookups are funcs of the running program rather than the generator,
+	// so the wrapper file declares RegisterHandleTypeWasm(typeName, lookup) for the program to call
+	HandleTypes []string
+	// count how often each resolver runs, reported to js by __resolverStats
+	ResolverStats bool
+	// resolve struct fields exposed as zero-arg methods (e.g. on class instances) by calling them
+	CallFieldMethods bool
+	// accept js arrays for struct values, resolving each field from its position
+	TupleStructs bool
+	// import path of the package generated code uses in place of syscall/js,
+	// e.g. a shim exposing a mockable Value for testing resolvers outside the browser
+	JSPackage string
+	// the identifier generated code refers to the js package by, importing it under that name
+	// (e.g. wjs for import wjs "syscall/js"). empty means the last element of its import path
+	JSPackageIdent string
+	// only assign struct fields present in the js object,
+	// starting from the defaults declared by a defaultName var or func for named structs
+	MergeDefaults bool
+	// leave struct fields whose property is undefined at their zero value rather than resolving them from undefined,
+	// fields tagged required throwing either way
+	SkipUndefinedFields bool
+	// wrap each function in a Promise so its body can await js values, rejecting it with returned errors,
+	// arguments may then be passed as promises, and struct arguments as ReadableStreams of json
+	Async bool
+	// complex numbers may also be passed in polar form as {r, theta} objects,
+	// rather than only as {re, im} or {real, imag}
+	PolarComplex bool
+	// return complex numbers as {real, imag} objects rather than {re, im}, either form is accepted
+	RealImagComplex bool
+	// the deepest nesting of structs, arrays and pointers that is resolved,
+	// present values nested any deeper throw instead. 0 means unlimited
+	MaxDepth int
+	// resolve elements of pointer arrays holding the same js object into a single shared pointer
+	SharePointers bool
+	// accept any js value for strings, converting non-strings with js String() (e.g. 42 to "42")
+	StringifyValues bool
+	// copy js typed arrays (e.g. Float32Array) straight into the memory of matching numeric slices (e.g. []float32),
+	// rather than resolving them element by element
+	UnsafeTypedArrays bool
+	// throw when js passes fewer arguments than a function has parameters,
+	// rather than resolving the missing ones from undefined
+	StrictArity bool
+	// accept bigints for int64 and uint64 values, which numbers can't hold exactly beyond 2^53,
+	// and return them as bigints
+	BigIntFor64 bool
+	// resolve each named struct, slice, map or pointer type through a wasmResolveType function
+	// generated once, rather than inline wherever the type is used
+	EmitNamedResolvers bool
+	// resolve maps and flattened structs from the enumerable properties a js object inherits
+	// through its prototype chain as well as its own, own properties taking precedence.
+	// struct fields are read with Get, which follows the chain either way
+	MergePrototype bool
+	// recover panics in wrappers, including those thrown by resolvers, and throw them to the js caller
+	// as catchable Errors rather than ending the program.
+	// the js functions throwing them are built with the Function constructor, which needs 'unsafe-eval' under a CSP
+	RecoverPanics bool
+	// throw the non-nil errors functions return as js Errors,
+	// rather than returning their message as data. wrappers recover and throw them as with RecoverPanics
+	ErrorsAsExceptions bool
+	// return {ok: true, value} from each function, or {ok: false, error} with the js Error
+	// it would have thrown or the error it returned, so callers needn't catch
+	EnvelopeReturns bool
+	// run the OnCallStartWasm and OnCallEndWasm hooks, when set at runtime, around each call
+	TraceCalls bool
+	// how struct field names are converted into js property names when no tag names the property
+	FieldNameStrategy FieldNameStrategy
+	// read struct fields whose property is absent from their go name, camelCase and snake_case forms in turn
+	// (e.g. UserID from userID or user_id), fields named by a tag excepted
+	KeyAliasing bool
+
+	// how fixed-size arrays are resolved from js arrays of another length
+	ArrayLengthMismatch ArrayLengthMismatch
+
+	// resolvers added with RegisterType, keyed by type
+	types map[string]*registeredType
+	// resolvers added with RegisterFieldResolver, keyed by field path
+	fields map[string]*registeredType
+	// transforms added with RegisterTransform, keyed by name
+	transforms map[string]*registeredTransform
 }
 
 func NewConfig() *Config {
@@ -36,3 +151,27 @@ func NewConfig() *Config {
 		AliasResolvers: true,
 	}
 }
+
+// a conversion of go field names into js property names
+type FieldNameStrategy int
+
+const (
+	// field names are used as is, e.g. UserID
+	AsIs FieldNameStrategy = iota
+	// field names are converted to camelCase, leading initialisms included, e.g. userID and httpStatus
+	CamelCase
+	// field names are converted to snake_case, e.g. user_id and http_status
+	SnakeCase
+)
+
+// a policy for resolving fixed-size arrays (e.g. [4]int) from js arrays of another length
+type ArrayLengthMismatch int
+
+const (
+	// shorter js arrays leave the remaining elements zero, longer ones are truncated
+	PadArrays ArrayLengthMismatch = iota
+	// longer js arrays are truncated, shorter ones throw
+	TruncateArrays
+	// js arrays of any other length throw
+	StrictArrays
+)
@@ -0,0 +1,551 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// returns the formatted wrapper file generated from the given source of package main
+func generate(t testing.TB, src string, config *Config) string {
+	t.Helper()
+	out, err := tryGenerate(src, config)
+	if err != nil {
+		t.Fatalf("Error generating wrappers: %v", err)
+	}
+
+	return out
+}
+
+// returns the formatted wrapper file generated from the given source of package main,
+// or the error generating it
+func tryGenerate(src string, config *Config) (string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "lib.go", src, parser.ParseComments)
+	if err != nil {
+		return "", err
+	}
+
+	pkg := &ast.Package{Name: "main", Files: map[string]*ast.File{"lib.go": file}}
+	wrapperFile, err := GenerateWrapperFile(pkg, config)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, wrapperFile); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// a temporary module holding a generated wasm library
+type wasmModule struct {
+	dir string
+}
+
+// writes the given source of package main, its wrappers and a main func evaluating script into a new module,
+// extra holding any other files of the module (e.g. "consts/consts.go")
+func newWasmModule(t testing.TB, src string, config *Config, script string, extra map[string]string) *wasmModule {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("Skipping wasm build in short mode")
+	}
+
+	mod := &wasmModule{dir: t.TempDir()}
+	files := map[string]string{
+		"go.mod":           "module wasmtest\n\ngo 1.18\n",
+		"lib.go":           src,
+		"wasm-wrappers.go": generate(t, src, config),
+		"main.go": `package main
+
+import (
+	"fmt"
+	"os"
+	"syscall/js"
+)
+
+func main() {
+	mainWasm()
+	defer func() {
+		// a js Error thrown by a wrapper ends the program, its message being printed
+		if r := recover(); r != nil {
+			if err, ok := r.(js.Value); ok {
+				println("Uncaught:", err.Get("message").String())
+				os.Exit(3)
+			}
+			panic(r)
+		}
+	}()
+	result := js.Global().Call("eval", ` + "`" + script + "`" + `)
+	if result.InstanceOf(js.Global().Get("Promise")) {
+		// an async script prints what its promise resolves to
+		resolved := make(chan js.Value, 1)
+		result.Call("then", js.FuncOf(func(this js.Value, args []js.Value) any {
+			resolved <- args[0]
+			return nil
+		}))
+		result = <-resolved
+	}
+	fmt.Println(result.String())
+}
+`,
+	}
+	for path, content := range extra {
+		files[path] = content
+	}
+
+	for path, content := range files {
+		path = filepath.Join(mod.dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return mod
+}
+
+// runs the go tool with the given args in the module for js/wasm
+func (mod *wasmModule) goCmd(args ...string) *exec.Cmd {
+	cmd := exec.Command("go", args...)
+	cmd.Dir = mod.dir
+	cmd.Env = append(os.Environ(), "GOOS=js", "GOARCH=wasm", "GOFLAGS=-mod=mod")
+	return cmd
+}
+
+// fails the test unless the module builds and vets for js/wasm
+func (mod *wasmModule) vet(t testing.TB) {
+	t.Helper()
+	if out, err := mod.goCmd("vet", ".").CombinedOutput(); err != nil {
+		wrappers, _ := os.ReadFile(filepath.Join(mod.dir, "wasm-wrappers.go"))
+		t.Fatalf("Generated code doesn't compile: %v\n%s\n%s", err, out, wrappers)
+	}
+}
+
+// runs the module with node, returning the printed result of its script,
+// or the output of the program when it didn't end normally
+func (mod *wasmModule) run(t testing.TB) (string, error) {
+	t.Helper()
+	out, err := mod.goCmd("run", "-exec="+wasmExecPath(t), ".").CombinedOutput()
+	return strings.TrimSpace(string(out)), err
+}
+
+// runs the benchmarks of the module with node, returning the metrics of each by name
+// (e.g. "BenchmarkCall/Array") and unit (e.g. "ns/op" or "allocs/op")
+func (mod *wasmModule) bench(t testing.TB) map[string]map[string]float64 {
+	t.Helper()
+	out, err := mod.goCmd("test", "-run=^$", "-bench=.", "-benchmem", "-exec="+wasmExecPath(t), ".").CombinedOutput()
+	if err != nil {
+		t.Fatalf("Error running wasm benchmarks: %v\n%s", err, out)
+	}
+
+	results := make(map[string]map[string]float64)
+	for _, line := range strings.Split(string(out), "\n") {
+		// e.g. BenchmarkCall/Array   	     100	  10580 ns/op	  48 B/op	  2 allocs/op
+		fields := strings.Fields(line)
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") {
+			continue
+		}
+
+		metrics := make(map[string]float64)
+		for i := 2; i+1 < len(fields); i += 2 {
+			value, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				t.Fatalf("Malformed benchmark result %s", line)
+			}
+
+			metrics[fields[i+1]] = value
+		}
+		results[fields[0]] = metrics
+	}
+
+	return results
+}
+
+// returns the path of the script running wasm binaries with node, skipping without node
+func wasmExecPath(t testing.TB) string {
+	t.Helper()
+	if _, err := exec.LookPath("node"); err != nil {
+		t.Skip("Skipping wasm run without node")
+	}
+
+	execPath := filepath.Join(runtime.GOROOT(), "lib", "wasm", "go_js_wasm_exec")
+	if _, err := os.Stat(execPath); err != nil {
+		execPath = filepath.Join(runtime.GOROOT(), "misc", "wasm", "go_js_wasm_exec")
+	}
+
+	return execPath
+}
+
+// builds and runs the given library, returning the result of evaluating script once its functions are exposed
+func runWasm(t *testing.T, src string, config *Config, script string) string {
+	t.Helper()
+	mod := newWasmModule(t, src, config, script, nil)
+	mod.vet(t)
+	out, err := mod.run(t)
+	if err != nil {
+		t.Fatalf("Error running wasm: %v\n%s", err, out)
+	}
+
+	return out
+}
+
+// builds and runs the given library, returning the message of the js Error
+// that ended the program while evaluating script
+func runWasmThrows(t *testing.T, src string, config *Config, script string) string {
+	t.Helper()
+	mod := newWasmModule(t, src, config, script, nil)
+	mod.vet(t)
+	out, err := mod.run(t)
+	if err == nil {
+		t.Fatalf("Expected a js Error to end the program, got %s", out)
+	}
+
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "Uncaught: ") {
+			return strings.TrimPrefix(line, "Uncaught: ")
+		}
+	}
+
+	t.Fatalf("Error running wasm: %v\n%s", err, out)
+	return ""
+}
+
+// benchmarks calls of the given library under js/wasm, each a js expression of the function called
+// on every iteration by name (e.g. "() => Len(data)"), reporting its metrics as a sub-benchmark of b.
+// calls may count events in the global benchCounts object by unit (e.g. benchCounts["gets/op"]),
+// reported per op alongside the timings
+func benchWasm(b *testing.B, src string, config *Config, calls map[string]string) {
+	b.Helper()
+	names := make([]string, 0, len(calls))
+	for name := range calls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var cases strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&cases, "\t\t{%q, %q},\n", name, calls[name])
+	}
+
+	mod := newWasmModule(b, src, config, `""`, map[string]string{
+		"bench_test.go": `package main
+
+import (
+	"syscall/js"
+	"testing"
+)
+
+func BenchmarkCall(b *testing.B) {
+	mainWasm()
+	for _, c := range []struct {
+		name string
+		call string
+	}{
+` + cases.String() + `	} {
+		call := js.Global().Call("eval", c.call)
+		b.Run(c.name, func(b *testing.B) {
+			counts := js.Global().Get("Object").New()
+			js.Global().Set("benchCounts", counts)
+			for i := 0; i < b.N; i++ {
+				call.Invoke()
+			}
+
+			units := js.Global().Get("Object").Call("keys", counts)
+			for j := 0; j < units.Length(); j++ {
+				unit := units.Index(j).String()
+				b.ReportMetric(counts.Get(unit).Float()/float64(b.N), unit)
+			}
+		})
+	}
+}
+`,
+	})
+	mod.vet(b)
+	results := mod.bench(b)
+	for _, name := range names {
+		metrics := results["BenchmarkCall/"+name]
+		b.Run(name, func(b *testing.B) {
+			// the calls were timed under js/wasm, so only their metrics are reported here
+			for unit, value := range metrics {
+				b.ReportMetric(value, unit)
+			}
+		})
+	}
+}
+
+// writes the given files into a new module and runs it on the host, returning its output
+func runHost(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	files["go.mod"] = "module wasmtest\n\ngo 1.18\n"
+	for path, content := range files {
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := exec.Command("go", "run", ".")
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("Error running module: %v\n%s", err, stderr.String())
+	}
+
+	return strings.TrimSpace(string(out))
+}
+
+func TestClientFile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping client build in short mode")
+	}
+
+	src := `package main
+
+import "strings"
+
+func Join(sep string, parts ...string) string {
+	return strings.Join(parts, sep)
+}
+
+func Pad(s string, _ int, n int) string {
+	return s + strings.Repeat(".", n)
+}
+
+func Log(msg string) {
+	println(msg)
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "lib.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clientFile, err := GenerateClientFile(&ast.Package{Name: "main", Files: map[string]*ast.File{"lib.go": file}}, nil)
+	if err != nil {
+		t.Fatalf("Error generating client: %v", err)
+	}
+
+	var client bytes.Buffer
+	if err := format.Node(&client, fset, clientFile); err != nil {
+		t.Fatal(err)
+	}
+
+	// the local client is called without syscall/js, so the module is built for the host
+	got := runHost(t, map[string]string{
+		"lib.go":         src,
+		"wasm-client.go": client.String(),
+		"main.go": `package main
+
+import "fmt"
+
+func main() {
+	var client ClientWasm = LocalClientWasm{}
+	client.Log("log")
+	fmt.Println(client.Join("-", "a", "b"), client.Pad("x", 0, 2))
+}
+`,
+	})
+	if got != "a-b x.." {
+		t.Errorf("Expected a-b x.., got %s", got)
+	}
+}
+
+func TestJSPackage(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping mock build in short mode")
+	}
+
+	src := `package main
+
+func Add(a, b int) int {
+	return a + b
+}
+`
+	config := NewConfig()
+	config.JSPackage = "wasmtest/mockjs"
+	got := runHost(t, map[string]string{
+		"lib.go":          src,
+		"wasm-wrapper.go": generate(t, src, config),
+		"mockjs/mockjs.go": `package mockjs
+
+type Value struct {
+	v any
+}
+
+type Func struct {
+	Value
+	fn func(this Value, args []Value) any
+}
+
+var globals = map[string]any{}
+
+func Global() Value {
+	return Value{globals}
+}
+
+func FuncOf(fn func(this Value, args []Value) any) Func {
+	return Func{fn: fn}
+}
+
+func (v Value) Set(key string, x any) {
+	v.v.(map[string]any)[key] = x
+}
+
+func ValueOf(x any) Value {
+	return Value{x}
+}
+
+func (v Value) Int() int {
+	return v.v.(int)
+}
+
+// calls the global func set under name with the given arguments
+func Call(name string, args ...any) any {
+	values := make([]Value, len(args))
+	for i, arg := range args {
+		values[i] = Value{arg}
+	}
+
+	result := globals[name].(Func).fn(Value{}, values)
+	if value, ok := result.(Value); ok {
+		return value.v
+	}
+
+	return result
+}
+`,
+		"main.go": `package main
+
+import (
+	"fmt"
+
+	"wasmtest/mockjs"
+)
+
+func main() {
+	mainWasm()
+	fmt.Println(mockjs.Call("Add", 1, 2))
+}
+`,
+	})
+	if got != "3" {
+		t.Errorf("Expected 3, got %s", got)
+	}
+}
+
+func TestOptionsCompile(t *testing.T) {
+	src := `package main
+
+import "errors"
+
+type Base struct {
+	ID int64
+}
+
+type Node struct {
+	Base
+	Name     string ` + "`js:\"name,required\"`" + `
+	Weights  []float64
+	Corners  [4]uint64
+	Labels   map[string]int
+	Children []*Node
+	Parent   *Node
+}
+
+func Walk(root Node, visit func(name string) bool) (int, error) {
+	if !visit(root.Name) {
+		return 0, errors.New("stopped")
+	}
+	return len(root.Weights), nil
+}
+
+func Mix(z complex128, data []byte, flags ...bool) (string, int) {
+	return string(data), len(flags)
+}
+`
+	// every bool option is compiled on its own over the defaults
+	configType := reflect.TypeOf(Config{})
+	for i := 0; i < configType.NumField(); i++ {
+		field := configType.Field(i)
+		if !field.IsExported() || field.Type.Kind() != reflect.Bool {
+			continue
+		}
+
+		t.Run(field.Name, func(t *testing.T) {
+			config := NewConfig()
+			option := reflect.ValueOf(config).Elem().FieldByName(field.Name)
+			option.SetBool(!option.Bool())
+			newWasmModule(t, src, config, `""`, nil).vet(t)
+		})
+	}
+}
+
+func TestJSPackageIdent(t *testing.T) {
+	src := `package main
+
+type Point struct {
+	X, Y int
+}
+
+func Scale(p *Point, factors []float64, tags map[string]bool) (int, error) {
+	return p.X * len(factors) * len(tags), nil
+}
+`
+	config := NewConfig()
+	config.JSPackageIdent = "wjs"
+	config.StrictArity = true
+	config.ErrorsAsExceptions = true
+	out := generate(t, src, config)
+
+	file, err := parser.ParseFile(token.NewFileSet(), "wasm-wrappers.go", out, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var aliased bool
+	for _, spec := range file.Imports {
+		if spec.Path.Value == `"syscall/js"` {
+			aliased = spec.Name != nil && spec.Name.Name == "wjs"
+		}
+	}
+	if !aliased {
+		t.Errorf("Expected syscall/js to be imported as wjs:\n%s", out)
+	}
+
+	ast.Inspect(file, func(node ast.Node) bool {
+		if selector, ok := node.(*ast.SelectorExpr); ok {
+			if ident, ok := selector.X.(*ast.Ident); ok && ident.Name == "js" {
+				t.Errorf("Expected every reference to use wjs, got js.%s", selector.Sel.Name)
+			}
+		}
+		return true
+	})
+
+	newWasmModule(t, src, config, `""`, nil).vet(t)
+}
@@ -0,0 +1,958 @@
+package generator
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"sort"
+)
+
+// a runtime helper that generated code can depend on
+type helper struct {
+	// import paths required by src
+	imports []string
+	// helpers used by src
+	deps []string
+	src  string
+}
+
+// helpers are emitted into the wrapper file once, the first time they're used
+var helpers = map[string]*helper{
+	"lazyArray": {
+		imports: []string{"syscall/js"},
+		src: `
+// the element getters of lazy arrays by id, released once their proxy is garbage collected
+var wasmLazyArrayGetters = make(map[int]js.Func)
+var wasmLazyArrayNextID int
+var wasmLazyArrayRegistry = js.Global().Get("FinalizationRegistry").New(js.FuncOf(func(this js.Value, args []js.Value) any {
+	id := args[0].Int()
+	wasmLazyArrayGetters[id].Release()
+	delete(wasmLazyArrayGetters, id)
+	return nil
+}))
+
+// returns a js Proxy of an array of the given length, getting each element from elt the first time it's accessed
+func wasmLazyArray(length int, elt func(int) any) js.Value {
+	getter := js.FuncOf(func(this js.Value, args []js.Value) any {
+		return elt(args[0].Int())
+	})
+	id := wasmLazyArrayNextID
+	wasmLazyArrayNextID++
+	wasmLazyArrayGetters[id] = getter
+
+	proxy := js.Global().Get("Function").New("length", "getter", ` + "`" + `const isIndex = (key) => typeof key === "string" && String(Number(key) >>> 0) === key && Number(key) < length;
+return new Proxy(new Array(length), {
+	get(target, key, receiver) {
+		if (isIndex(key) && !(key in target)) {
+			target[key] = getter(Number(key));
+		}
+		return Reflect.get(target, key, receiver);
+	},
+	has(target, key) {
+		return isIndex(key) || Reflect.has(target, key);
+	},
+	getOwnPropertyDescriptor(target, key) {
+		if (isIndex(key) && !(key in target)) {
+			target[key] = getter(Number(key));
+		}
+		return Reflect.getOwnPropertyDescriptor(target, key);
+	},
+	ownKeys(target) {
+		const keys = Reflect.ownKeys(target).filter((key) => !isIndex(key));
+		return Array.from({length}, (_, i) => String(i)).concat(keys);
+	},
+});` + "`" + `).Invoke(length, getter)
+	wasmLazyArrayRegistry.Call("register", proxy, id)
+	return proxy
+}
+`,
+	},
+	"handles": {
+		imports: []string{"syscall/js"},
+		src: `
+var wasmHandleTables = make(map[string]func(int) any)
+
+// RegisterHandleTypeWasm sets the lookup used to resolve js handles into values of the named type
+func RegisterHandleTypeWasm(typeName string, lookup func(int) any) {
+	wasmHandleTables[typeName] = lookup
+}
+
+func wasmLookupHandle(typeName string, handle int) any {
+	lookup, ok := wasmHandleTables[typeName]
+	if !ok {
+		panic(js.Global().Get("Error").New("No handle table registered for " + typeName))
+	}
+
+	return lookup(handle)
+}
+`,
+	},
+	"promise": {
+		imports: []string{"syscall/js"},
+		deps:    []string{"error"},
+		src: `
+// runs body on a new goroutine, returning a Promise that resolves with its result
+// or rejects with a js Error if it panics or returns a non-nil error
+func wasmPromise(body func() any) js.Value {
+	executor := js.FuncOf(func(this js.Value, args []js.Value) any {
+		resolve, reject := args[0], args[1]
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					reject.Invoke(wasmErrorValue(r))
+				}
+			}()
+
+			result := body()
+			if err, ok := result.(error); ok {
+				reject.Invoke(wasmErrorValue(err))
+				return
+			}
+
+			resolve.Invoke(result)
+		}()
+
+		return nil
+	})
+	defer executor.Release()
+
+	return js.Global().Get("Promise").New(executor)
+}
+`,
+	},
+	"error": {
+		imports: []string{"fmt", "syscall/js"},
+		src: `
+// returns the js Error for a recovered panic or returned error, thrown js values as is
+func wasmErrorValue(r any) js.Value {
+	switch r := r.(type) {
+	case js.Value:
+		return r
+	case error:
+		return js.Global().Get("Error").New(r.Error())
+	default:
+		return js.Global().Get("Error").New(fmt.Sprint(r))
+	}
+}
+`,
+	},
+	"recover": {
+		imports: []string{"syscall/js"},
+		deps:    []string{"error"},
+		src: `
+// marks the results of wrappers that recovered a panic,
+// which can't unwind into js and would otherwise end the program
+var wasmThrowMarker = js.Global().Get("Symbol").Invoke("wasm throw")
+
+// recovers a panic of the wrapper deferring it, setting its result to the marked js Error
+func wasmRecover(result *any) {
+	if r := recover(); r != nil {
+		marked := js.Global().Get("Object").New()
+		js.Global().Get("Reflect").Call("set", marked, wasmThrowMarker, wasmErrorValue(r))
+		*result = marked
+	}
+}
+
+// returns the js Error marked in the result of a wrapper that recovered a panic, other results as is
+func wasmUnmarkResult(result any) any {
+	if marked, ok := result.(js.Value); ok && marked.Type() == js.TypeObject {
+		if js.Global().Get("Reflect").Call("has", marked, wasmThrowMarker).Bool() {
+			return js.Global().Get("Reflect").Call("get", marked, wasmThrowMarker)
+		}
+	}
+
+	return result
+}
+
+// returns a js function calling fn that throws the errors marked in its results.
+// the function is built with the Function constructor, which a Content-Security-Policy
+// without 'unsafe-eval' doesn't allow
+func wasmThrowingFunc(fn js.Func) js.Value {
+	return js.Global().Get("Function").New("fn", "marker", ` + "`" + `return function (...args) {
+	const result = fn.apply(this, args);
+	if (typeof result === "object" && result !== null && marker in result) {
+		throw result[marker];
+	}
+	return result;
+};` + "`" + `).Invoke(fn, wasmThrowMarker)
+}
+`,
+	},
+	"envelope": {
+		deps: []string{"error"},
+		src: `
+// runs body, returning {ok: true, value} with its result,
+// or {ok: false, error} if it panics or returns a non-nil error
+func wasmEnvelope(body func() any) (envelope any) {
+	defer func() {
+		if r := recover(); r != nil {
+			envelope = map[string]any{"ok": false, "error": wasmErrorValue(r)}
+		}
+	}()
+
+	result := body()
+	if err, ok := result.(error); ok {
+		return map[string]any{"ok": false, "error": wasmErrorValue(err)}
+	}
+
+	return map[string]any{"ok": true, "value": result}
+}
+`,
+	},
+	"typedarray": {
+		imports: []string{"syscall/js", "unsafe"},
+		src: `
+// copies the size bytes of the typed array src into the memory at dst
+func wasmCopyTypedArray(dst unsafe.Pointer, size int, src js.Value) {
+	bytes := js.Global().Get("Uint8Array").New(src.Get("buffer"), src.Get("byteOffset"), src.Get("byteLength"))
+	js.CopyBytesToGo(unsafe.Slice((*byte)(dst), size), bytes)
+}
+`,
+	},
+	"reuse": {
+		imports: []string{"syscall/js"},
+		src: `
+var wasmReusedObjects = make(map[string]js.Value)
+
+// returns the js object the results of the named function are encoded into,
+// the same object being returned by every call
+func wasmReusedObject(name string) js.Value {
+	object, ok := wasmReusedObjects[name]
+	if !ok {
+		object = js.Global().Get("Object").New()
+		wasmReusedObjects[name] = object
+	}
+
+	return object
+}
+`,
+	},
+	"trace": {
+		imports: []string{"fmt", "syscall/js"},
+		src: `
+// OnCallStartWasm, when set, is called with the name of each wrapped function as it's called
+var OnCallStartWasm func(name string)
+
+// OnCallEndWasm, when set, is called with the name of each wrapped function once it returns,
+// along with the error it returned or threw (nil if it succeeded)
+var OnCallEndWasm func(name string, err error)
+
+// runs body between the call hooks, rethrowing anything it throws once OnCallEndWasm has seen it
+func wasmTraceCall(name string, body func() any) (result any) {
+	if OnCallStartWasm != nil {
+		OnCallStartWasm(name)
+	}
+
+	defer func() {
+		if OnCallEndWasm == nil {
+			return
+		}
+
+		r := recover()
+		var err error
+		switch r := r.(type) {
+		case nil:
+			err, _ = result.(error)
+		case js.Value:
+			err = js.Error{Value: r}
+		case error:
+			err = r
+		default:
+			err = fmt.Errorf("%v", r)
+		}
+
+		OnCallEndWasm(name, err)
+		if r != nil {
+			panic(r)
+		}
+	}()
+
+	return body()
+}
+`,
+	},
+	"await": {
+		imports: []string{"syscall/js"},
+		src: `
+// blocks until promise settles, returning its value or panicking with its rejection reason.
+// must not be called from the event loop goroutine
+func wasmAwait(promise js.Value) js.Value {
+	fulfilled := make(chan js.Value, 1)
+	rejected := make(chan js.Value, 1)
+
+	onFulfilled := js.FuncOf(func(this js.Value, args []js.Value) any {
+		fulfilled <- args[0]
+		return nil
+	})
+	defer onFulfilled.Release()
+
+	onRejected := js.FuncOf(func(this js.Value, args []js.Value) any {
+		rejected <- args[0]
+		return nil
+	})
+	defer onRejected.Release()
+
+	js.Global().Get("Promise").Call("resolve", promise).Call("then", onFulfilled, onRejected)
+	select {
+	case value := <-fulfilled:
+		return value
+	case reason := <-rejected:
+		panic(reason)
+	}
+}
+
+// returns the value a thenable argument settles to, other values are returned as is
+func wasmAwaitArg(value js.Value) js.Value {
+	if value.Type() == js.TypeObject && value.Get("then").Type() == js.TypeFunction {
+		return wasmAwait(value)
+	}
+
+	return value
+}
+`,
+	},
+	"stream": {
+		imports: []string{"encoding/json", "syscall/js"},
+		deps:    []string{"await"},
+		src: `
+func wasmIsStream(value js.Value) bool {
+	readableStream := js.Global().Get("ReadableStream")
+	return readableStream.Truthy() && value.InstanceOf(readableStream)
+}
+
+// drains a ReadableStream of Uint8Array chunks and decodes the json it holds into dst
+func wasmUnmarshalStream(stream js.Value, dst any) {
+	reader := stream.Call("getReader")
+	defer reader.Call("releaseLock")
+
+	var data []byte
+	for {
+		chunk := wasmAwait(reader.Call("read"))
+		if chunk.Get("done").Bool() {
+			break
+		}
+
+		value := chunk.Get("value")
+		buf := make([]byte, value.Length())
+		js.CopyBytesToGo(buf, value)
+		data = append(data, buf...)
+	}
+
+	err := json.Unmarshal(data, dst)
+	if err != nil {
+		panic(err)
+	}
+}
+`,
+	},
+	"schema": {
+		imports: []string{"math", "strconv", "strings", "syscall/js"},
+		src: `
+var wasmSchemas = make(map[string]js.Value)
+
+// validates value against a subset of JSON Schema
+// (type, enum, minimum, maximum, minLength, maxLength, minItems, maxItems, required, properties and items),
+// throwing a js Error listing every problem found
+func wasmValidateSchema(schemaSrc string, value js.Value, path string) {
+	schema, ok := wasmSchemas[schemaSrc]
+	if !ok {
+		schema = js.Global().Get("JSON").Call("parse", schemaSrc)
+		wasmSchemas[schemaSrc] = schema
+	}
+
+	var problems []string
+	wasmSchemaProblems(schema, value, path, &problems)
+	if len(problems) > 0 {
+		panic(js.Global().Get("Error").New("Schema validation failed: " + strings.Join(problems, "; ")))
+	}
+}
+
+func wasmSchemaProblems(schema js.Value, value js.Value, path string, problems *[]string) {
+	isArray := js.Global().Get("Array").Call("isArray", value).Bool()
+	if schemaType := schema.Get("type"); schemaType.Type() == js.TypeString {
+		var ok bool
+		switch schemaType.String() {
+		case "null":
+			ok = value.IsNull()
+		case "boolean":
+			ok = value.Type() == js.TypeBoolean
+		case "number":
+			ok = value.Type() == js.TypeNumber
+		case "integer":
+			ok = value.Type() == js.TypeNumber && value.Float() == math.Trunc(value.Float())
+		case "string":
+			ok = value.Type() == js.TypeString
+		case "array":
+			ok = isArray
+		case "object":
+			ok = value.Type() == js.TypeObject && !isArray
+		default:
+			ok = true
+		}
+
+		if !ok {
+			*problems = append(*problems, path+" must be of type "+schemaType.String())
+			return
+		}
+	}
+
+	if enum := schema.Get("enum"); enum.Truthy() {
+		var ok bool
+		for i := 0; i < enum.Length() && !ok; i++ {
+			ok = enum.Index(i).Equal(value)
+		}
+
+		if !ok {
+			*problems = append(*problems, path+" must be one of "+js.Global().Get("JSON").Call("stringify", enum).String())
+		}
+	}
+
+	wasmSchemaBounds(schema, "minimum", "maximum", value, path, problems)
+	if value.Type() == js.TypeString {
+		wasmSchemaBounds(schema, "minLength", "maxLength", js.ValueOf(len([]rune(value.String()))), path+".length", problems)
+	} else if isArray {
+		wasmSchemaBounds(schema, "minItems", "maxItems", js.ValueOf(value.Length()), path+".length", problems)
+	}
+
+	if isArray {
+		if items := schema.Get("items"); items.Type() == js.TypeObject {
+			for i := 0; i < value.Length(); i++ {
+				wasmSchemaProblems(items, value.Index(i), path+"["+strconv.Itoa(i)+"]", problems)
+			}
+		}
+	} else if value.Type() == js.TypeObject {
+		if required := schema.Get("required"); required.Truthy() {
+			for i := 0; i < required.Length(); i++ {
+				if key := required.Index(i).String(); value.Get(key).IsUndefined() {
+					*problems = append(*problems, path+"."+key+" is required")
+				}
+			}
+		}
+
+		if properties := schema.Get("properties"); properties.Type() == js.TypeObject {
+			keys := js.Global().Get("Object").Call("keys", properties)
+			for i := 0; i < keys.Length(); i++ {
+				key := keys.Index(i).String()
+				if property := value.Get(key); !property.IsUndefined() {
+					wasmSchemaProblems(properties.Get(key), property, path+"."+key, problems)
+				}
+			}
+		}
+	}
+}
+
+func wasmSchemaBounds(schema js.Value, minKey string, maxKey string, value js.Value, path string, problems *[]string) {
+	if value.Type() != js.TypeNumber {
+		return
+	}
+
+	if min := schema.Get(minKey); min.Type() == js.TypeNumber && value.Float() < min.Float() {
+		*problems = append(*problems, path+" must be at least "+strconv.FormatFloat(min.Float(), 'g', -1, 64))
+	}
+
+	if max := schema.Get(maxKey); max.Type() == js.TypeNumber && value.Float() > max.Float() {
+		*problems = append(*problems, path+" must be at most "+strconv.FormatFloat(max.Float(), 'g', -1, 64))
+	}
+}
+`,
+	},
+	"time": {
+		imports: []string{"strings", "syscall/js", "time"},
+		src: `
+// returns the month numbered or named (e.g. "January") by value
+func wasmResolveMonth(value js.Value) time.Month {
+	if value.Type() != js.TypeString {
+		return time.Month(value.Int())
+	}
+
+	for month := time.January; month <= time.December; month++ {
+		if strings.EqualFold(month.String(), value.String()) {
+			return month
+		}
+	}
+
+	panic(js.Global().Get("Error").New("Unknown month " + value.String()))
+}
+
+// returns the weekday numbered or named (e.g. "Sunday") by value
+func wasmResolveWeekday(value js.Value) time.Weekday {
+	if value.Type() != js.TypeString {
+		return time.Weekday(value.Int())
+	}
+
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		if strings.EqualFold(weekday.String(), value.String()) {
+			return weekday
+		}
+	}
+
+	panic(js.Global().Get("Error").New("Unknown weekday " + value.String()))
+}
+
+// returns the duration in nanoseconds or parsed from a duration string (e.g. "1h30m") by value
+func wasmResolveDuration(value js.Value) time.Duration {
+	if value.Type() != js.TypeString {
+		return time.Duration(value.Int())
+	}
+
+	duration, err := time.ParseDuration(value.String())
+	if err != nil {
+		panic(js.Global().Get("Error").New(err.Error()))
+	}
+
+	return duration
+}
+`,
+	},
+	"abort": {
+		imports: []string{"syscall/js"},
+		src: `
+// returns a channel closed when signal aborts.
+// the abort listener is only released once the signal aborts
+func wasmAbortChan(signal js.Value) chan struct{} {
+	done := make(chan struct{})
+	if signal.IsUndefined() || signal.IsNull() {
+		return done
+	}
+
+	if signal.Get("aborted").Bool() {
+		close(done)
+		return done
+	}
+
+	var onAbort js.Func
+	onAbort = js.FuncOf(func(this js.Value, args []js.Value) any {
+		close(done)
+		onAbort.Release()
+		return nil
+	})
+	signal.Call("addEventListener", "abort", onAbort, map[string]any{"once": true})
+
+	return done
+}
+`,
+	},
+	"cancel": {
+		imports: []string{"context", "syscall/js"},
+		src: `
+// returns a cancel func invoking the js function fn, or doing nothing if fn isn't a function
+func wasmCancelFunc(fn js.Value) context.CancelFunc {
+	return func() {
+		if fn.Type() == js.TypeFunction {
+			fn.Invoke()
+		}
+	}
+}
+`,
+	},
+	"indexmap": {
+		imports: []string{"strconv", "syscall/js"},
+		src: `
+// returns the non-negative integer keys of value
+// and the length of the slice needed to hold them by index
+func wasmIndexKeys(value js.Value) ([]int, int) {
+	var length int
+	var indexes []int
+
+	keys := js.Global().Get("Object").Call("keys", value)
+	for i := 0; i < keys.Length(); i++ {
+		index, err := strconv.Atoi(keys.Index(i).String())
+		if err != nil || index < 0 {
+			continue
+		}
+
+		indexes = append(indexes, index)
+		if index >= length {
+			length = index + 1
+		}
+	}
+
+	return indexes, length
+}
+`,
+	},
+	"stringify": {
+		imports: []string{"syscall/js"},
+		src: `
+// returns the string form of value as given by js String()
+func wasmStringify(value js.Value) string {
+	if value.Type() == js.TypeString {
+		return value.String()
+	}
+
+	return js.Global().Get("String").Invoke(value).String()
+}
+`,
+	},
+	"json": {
+		imports: []string{"syscall/js"},
+		src: `
+// returns the JSON encoding of value, null for values JSON can't encode (e.g. undefined or functions)
+func wasmStringifyJSON(value js.Value) string {
+	encoded := js.Global().Get("JSON").Call("stringify", value)
+	if encoded.IsUndefined() {
+		return "null"
+	}
+
+	return encoded.String()
+}
+`,
+	},
+	"prototype": {
+		imports: []string{"syscall/js"},
+		src: `
+// returns an object holding the enumerable properties of value and its prototypes short of Object.prototype,
+// properties nearer value shadowing those further up the chain
+func wasmMergePrototype(value js.Value) js.Value {
+	object := js.Global().Get("Object")
+	root := object.Get("prototype")
+
+	var chain []js.Value
+	for proto := value; proto.Type() == js.TypeObject && !proto.Equal(root); proto = object.Call("getPrototypeOf", proto) {
+		chain = append(chain, proto)
+	}
+
+	// assigned from the furthest prototype down so nearer properties win
+	merged := object.New()
+	for i := len(chain) - 1; i >= 0; i-- {
+		object.Call("assign", merged, chain[i])
+	}
+
+	return merged
+}
+`,
+	},
+	"split": {
+		imports: []string{"strings", "syscall/js"},
+		src: `
+// returns the array of the parts of a string value split on delimiter, converted to numbers when numeric,
+// the empty string having no parts. non-string values are returned as they are
+func wasmSplitParts(value js.Value, delimiter string, numeric bool) js.Value {
+	if value.Type() != js.TypeString {
+		return value
+	}
+
+	if value.String() == "" {
+		return js.Global().Get("Array").New()
+	}
+
+	parts := make([]any, 0)
+	for _, part := range strings.Split(value.String(), delimiter) {
+		parts = append(parts, part)
+	}
+
+	if numeric {
+		return js.ValueOf(parts).Call("map", js.Global().Get("Number"))
+	}
+
+	return js.ValueOf(parts)
+}
+`,
+	},
+	"subarray": {
+		imports: []string{"fmt", "syscall/js"},
+		src: `
+// returns the window of value starting at offset and holding length elements, or running to its end
+// when length is undefined. typed arrays are windowed with subarray, sharing their buffer
+func wasmSubarray(value js.Value, offset js.Value, length js.Value) js.Value {
+	begin := offset.Int()
+	end := value.Length()
+	if !length.IsUndefined() {
+		end = begin + length.Int()
+	}
+
+	if begin < 0 || end < begin || end > value.Length() {
+		panic(js.Global().Get("Error").New(fmt.Sprintf("Window [%d:%d] out of range for length %d", begin, end, value.Length())))
+	}
+
+	if value.Get("subarray").Type() == js.TypeFunction {
+		return value.Call("subarray", begin, end)
+	}
+
+	return value.Call("slice", begin, end)
+}
+`,
+	},
+	"bigint": {
+		imports: []string{"fmt", "math", "strconv", "syscall/js"},
+		src: `
+// returns the int64 held by a js number or bigint, throwing for numbers that aren't integers in its range.
+// bigints have no js.Type, so they're told apart from finite numbers with Number.isFinite
+func wasmResolveInt64(value js.Value) int64 {
+	if js.Global().Get("Number").Call("isFinite", value).Bool() {
+		n := value.Float()
+		if n != math.Trunc(n) || n < math.MinInt64 || n >= math.MaxInt64 {
+			panic(js.Global().Get("Error").New(fmt.Sprintf("Invalid int64: %v is not an integer in range", n)))
+		}
+
+		return int64(n)
+	}
+
+	n, err := strconv.ParseInt(js.Global().Get("String").Invoke(value).String(), 10, 64)
+	if err != nil {
+		panic(js.Global().Get("Error").New("Invalid int64: " + err.Error()))
+	}
+
+	return n
+}
+
+// returns the uint64 held by a js number or bigint, throwing for numbers that aren't integers in its range
+func wasmResolveUint64(value js.Value) uint64 {
+	if js.Global().Get("Number").Call("isFinite", value).Bool() {
+		n := value.Float()
+		if n != math.Trunc(n) || n < 0 || n >= math.MaxUint64 {
+			panic(js.Global().Get("Error").New(fmt.Sprintf("Invalid uint64: %v is not an integer in range", n)))
+		}
+
+		return uint64(n)
+	}
+
+	n, err := strconv.ParseUint(js.Global().Get("String").Invoke(value).String(), 10, 64)
+	if err != nil {
+		panic(js.Global().Get("Error").New("Invalid uint64: " + err.Error()))
+	}
+
+	return n
+}
+
+// returns n as a js bigint
+func wasmInt64BigInt(n int64) js.Value {
+	return js.Global().Get("BigInt").Invoke(strconv.FormatInt(n, 10))
+}
+
+// returns n as a js bigint
+func wasmUint64BigInt(n uint64) js.Value {
+	return js.Global().Get("BigInt").Invoke(strconv.FormatUint(n, 10))
+}
+`,
+	},
+	"pull": {
+		imports: []string{"syscall/js"},
+		src: `
+// returns the next value of a js pull callback or iterator, and false once it's done.
+// results are {value, done} objects as returned by an iterator's next, undefined also ending the values
+func wasmPull(source js.Value) (js.Value, bool) {
+	var next js.Value
+	if source.Type() == js.TypeFunction {
+		next = source.Invoke()
+	} else {
+		next = source.Call("next")
+	}
+
+	if next.Type() != js.TypeObject || next.Get("done").Truthy() {
+		return js.Undefined(), false
+	}
+
+	return next.Get("value"), true
+}
+`,
+	},
+	"columns": {
+		imports: []string{"syscall/js"},
+		src: `
+// returns the arrays of value under keys, undefined where absent, and their shared length.
+// arrays of differing lengths throw
+func wasmColumns(value js.Value, keys ...string) ([]js.Value, int) {
+	columns := make([]js.Value, len(keys))
+	length := -1
+	for i, key := range keys {
+		columns[i] = value.Get(key)
+		if columns[i].IsUndefined() || columns[i].IsNull() {
+			columns[i] = js.Undefined()
+			continue
+		}
+
+		if length >= 0 && columns[i].Length() != length {
+			panic(js.Global().Get("Error").New("Column " + key + " differs in length from the columns before it"))
+		}
+
+		length = columns[i].Length()
+	}
+
+	if length < 0 {
+		return columns, 0
+	}
+
+	return columns, length
+}
+`,
+	},
+	"resolveError": {
+		imports: []string{"errors", "syscall/js"},
+		src: `
+// returns an error holding the message of a js Error, or a string or any other value as js String() formats it,
+// nil for null and undefined
+func wasmResolveError(value js.Value) error {
+	if value.IsUndefined() || value.IsNull() {
+		return nil
+	}
+
+	if value.Type() == js.TypeObject {
+		if message := value.Get("message"); message.Type() == js.TypeString {
+			return errors.New(message.String())
+		}
+	}
+
+	return errors.New(js.Global().Call("String", value).String())
+}
+`,
+	},
+	"complex": {
+		imports: []string{"syscall/js"},
+		src: `
+// returns the complex number held by an {re, im} or {real, imag} object
+func wasmResolveComplex(value js.Value) complex128 {
+	if re := value.Get("real"); !re.IsUndefined() {
+		return complex(re.Float(), value.Get("imag").Float())
+	}
+
+	return complex(value.Get("re").Float(), value.Get("im").Float())
+}
+`,
+	},
+	"polar": {
+		imports: []string{"math/cmplx", "syscall/js"},
+		deps:    []string{"complex"},
+		src: `
+// returns the complex number held by an {r, theta}, {re, im} or {real, imag} object
+func wasmResolvePolarComplex(value js.Value) complex128 {
+	if r := value.Get("r"); !r.IsUndefined() {
+		return cmplx.Rect(r.Float(), value.Get("theta").Float())
+	}
+
+	return wasmResolveComplex(value)
+}
+`,
+	},
+	"flatten": {
+		imports: []string{"strings", "syscall/js"},
+		src: `
+// returns an object holding the dotted keys of value (e.g. {"a.b": 1}) as nested objects (e.g. {a: {b: 1}})
+func wasmUnflatten(value js.Value) js.Value {
+	object := js.Global().Get("Object")
+	nested := object.New()
+
+	keys := object.Call("keys", value)
+	for i := 0; i < keys.Length(); i++ {
+		key := keys.Index(i).String()
+		path := strings.Split(key, ".")
+
+		parent := nested
+		for _, part := range path[:len(path)-1] {
+			child := parent.Get(part)
+			if child.Type() != js.TypeObject {
+				child = object.New()
+				parent.Set(part, child)
+			}
+
+			parent = child
+		}
+
+		parent.Set(path[len(path)-1], value.Get(key))
+	}
+
+	return nested
+}
+`,
+	},
+}
+
+// marks the named helper and the helpers it depends on to be emitted into the wrapper file
+func (gen *generator) useHelper(name string) {
+	gen.helpers[name] = true
+	for _, dep := range helpers[name].deps {
+		gen.useHelper(dep)
+	}
+}
+
+// returns the declarations of every used helper
+func (gen *generator) helperDecls() ([]ast.Decl, error) {
+	names := make([]string, 0, len(gen.helpers))
+	for name := range gen.helpers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	decls := make([]ast.Decl, 0)
+	for _, name := range names {
+		h := helpers[name]
+		file, err := parser.ParseFile(token.NewFileSet(), name, "package helpers\n"+h.src, 0)
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing helper \"%s\": %v", name, err)
+		}
+
+		for _, path := range h.imports {
+			if path == "syscall/js" {
+				path = gen.jsPackage()
+			}
+
+			gen.imports[path] = true
+		}
+
+		for _, decl := range file.Decls {
+			clearPositions(decl)
+			gen.renameJSIdent(decl)
+			decls = append(decls, decl)
+		}
+	}
+
+	return decls, nil
+}
+
+// points every js selector in a parsed helper at the configured js package
+func (gen *generator) renameJSIdent(node ast.Node) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if pkgIdent, ok := sel.X.(*ast.Ident); ok && pkgIdent.Name == "js" {
+				sel.X = gen.jsIdent()
+			}
+		}
+
+		return true
+	})
+}
+
+// zeroes every token.Pos reachable from node
+// so parsed helpers print cleanly alongside generated nodes
+func clearPositions(node ast.Node) {
+	posType := reflect.TypeOf(token.NoPos)
+	ast.Inspect(node, func(n ast.Node) bool {
+		if n == nil {
+			return false
+		}
+
+		v := reflect.ValueOf(n)
+		if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+			return true
+		}
+
+		// empty braces and parens stay valid so they print on one line (e.g. struct{})
+		emptyList, ok := n.(*ast.FieldList)
+		if ok && (len(emptyList.List) > 0 || emptyList.Opening == token.NoPos) {
+			emptyList = nil
+		}
+
+		v = v.Elem()
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Field(i)
+			if field.Type() != posType || !field.CanSet() {
+				continue
+			}
+
+			// a call's Ellipsis position also marks it as variadic, so it stays valid
+			if v.Type().Field(i).Name == "Ellipsis" && field.Int() != int64(token.NoPos) {
+				field.SetInt(1)
+			} else {
+				field.SetInt(int64(token.NoPos))
+			}
+		}
+
+		if emptyList != nil {
+			emptyList.Opening, emptyList.Closing = 1, 1
+		}
+
+		return true
+	})
+}
@@ -0,0 +1,1317 @@
+package generator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// resolves a parameter described by a
+// 	//wasm:union=field param key:Type...
+// directive into the variant type selected by the value of the js object's field.
+// keys are compared as ints when they're all integers and as strings otherwise,
+// and variants may be any type expression, e.g. 1:*Square
+//
+// generated resolver:
+// 	var name T
+// 	switch jsValue.Get("field").Int() {
+// 	case key:
+// 		...
+// 		name = nameVariant0
+// 	default:
+// 		panic(js.Global().Get("Error").New(...))
+// 	}
+func (gen *generator) resolveUnion(
+	name *ast.Ident,
+	jsValue ast.Expr,
+	nativeType ast.Expr,
+	union *directive,
+) (expr ast.Expr, resolver []ast.Stmt, err error) {
+	if union.value == "" {
+		return nil, nil, fmt.Errorf("//wasm:union requires a discriminant field")
+	}
+
+	method := "Int"
+	keyKind := token.INT
+	cases := make([]ast.Stmt, 0, len(union.args))
+	for _, variant := range union.args {
+		key, typeSrc, ok := strings.Cut(variant, ":")
+		if !ok {
+			continue // the parameter name
+		}
+
+		if _, err := strconv.Atoi(key); err != nil {
+			method = "String"
+			keyKind = token.STRING
+		}
+
+		variantType, err := parser.ParseExpr(typeSrc)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Malformed union variant %s: %v", variant, err)
+		}
+		clearPositions(variantType)
+
+		variantExpr, variantResolver, err := gen.ResolveValue(
+			// named by position, as variants like []Item don't make identifiers
+			&ast.Ident{Name: name.Name + "Variant" + strconv.Itoa(len(cases))},
+			jsValue,
+			variantType,
+			nil,
+		)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Unresolved union variant %s: %v", typeSrc, err)
+		}
+
+		// named structs are resolved as struct literals, converted back for their methods
+		if _, ok := variantType.(*ast.Ident); ok {
+			variantExpr = &ast.CallExpr{Fun: variantType, Args: []ast.Expr{variantExpr}}
+		}
+
+		cases = append(cases, &ast.CaseClause{
+			List: []ast.Expr{&ast.BasicLit{Value: key}},
+			Body: append(variantResolver, &ast.AssignStmt{
+				Lhs: []ast.Expr{name},
+				Tok: token.ASSIGN,
+				Rhs: []ast.Expr{variantExpr},
+			}),
+		})
+	}
+
+	for _, c := range cases {
+		key := c.(*ast.CaseClause).List[0].(*ast.BasicLit)
+		key.Kind = keyKind
+		if keyKind == token.STRING {
+			key.Value = strconv.Quote(key.Value)
+		}
+	}
+
+	cases = append(cases, &ast.CaseClause{
+		Body: []ast.Stmt{
+			gen.throwStmt(&ast.BasicLit{
+				Kind:  token.STRING,
+				Value: strconv.Quote(fmt.Sprintf("Unknown %s for %s", union.value, name.Name)),
+			}),
+		},
+	})
+
+	return name, []ast.Stmt{
+		&ast.DeclStmt{
+			Decl: &ast.GenDecl{
+				Tok: token.VAR,
+				Specs: []ast.Spec{
+					&ast.ValueSpec{
+						Names: []*ast.Ident{name},
+						Type:  nativeType,
+					},
+				},
+			},
+		},
+		&ast.SwitchStmt{
+			Tag: &ast.CallExpr{
+				Fun: &ast.SelectorExpr{
+					X: &ast.CallExpr{
+						Fun: &ast.SelectorExpr{
+							X:   jsValue,
+							Sel: &ast.Ident{Name: "Get"},
+						},
+						Args: []ast.Expr{
+							&ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(union.value)},
+						},
+					},
+					Sel: &ast.Ident{Name: method},
+				},
+			},
+			Body: &ast.BlockStmt{List: cases},
+		},
+	}, err
+}
+
+// returns a statement validating a parameter against the JSON Schema file named by a
+// 	//wasm:schema=path param
+// directive, the path being relative to the package directory.
+// the schema is embedded in the generated code and checked before the parameter is resolved
+//
+// generated validation:
+// 	wasmValidateSchema("{...}", jsValue, "name")
+func (gen *generator) validateSchema(name *ast.Ident, jsValue ast.Expr, schema *directive) (ast.Stmt, error) {
+	if schema.value == "" {
+		return nil, fmt.Errorf("//wasm:schema requires a schema path")
+	}
+
+	schemaPath := schema.value
+	for fileName := range gen.pkg.Files {
+		if !filepath.IsAbs(schemaPath) {
+			schemaPath = filepath.Join(filepath.Dir(fileName), schemaPath)
+		}
+		break
+	}
+
+	schemaSrc, err := os.ReadFile(schemaPath)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading schema: %v", err)
+	}
+
+	var compacted bytes.Buffer
+	err = json.Compact(&compacted, schemaSrc)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid schema %s: %v", schema.value, err)
+	}
+
+	gen.useHelper("schema")
+	return &ast.ExprStmt{
+		X: &ast.CallExpr{
+			Fun: &ast.Ident{Name: "wasmValidateSchema"},
+			Args: []ast.Expr{
+				&ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(compacted.String())},
+				jsValue,
+				&ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(name.Name)},
+			},
+		},
+	}, nil
+}
+
+// resolves a parameter described by a
+// 	//wasm:options param
+// directive, an options struct whose fields are taken from the remaining js arguments by position.
+// pointer fields are left nil when their argument is absent, other fields keep their zero value
+//
+// generated resolver:
+// 	var name T
+// 	if len(args) > i && !args[i].IsUndefined() && !args[i].IsNull() {
+// 		var nameField F
+// 		...
+// 		name.Field = &nameField
+// 	}
+func (gen *generator) resolveOptions(
+	name *ast.Ident,
+	argIdx int,
+	nativeType ast.Expr,
+) (expr ast.Expr, resolver []ast.Stmt, err error) {
+	structType, ok := gen.underlyingType(nativeType).(*ast.StructType)
+	if !ok {
+		return nil, nil, fmt.Errorf("//wasm:options requires a struct parameter")
+	}
+
+	resolver = append(resolver, &ast.DeclStmt{
+		Decl: &ast.GenDecl{
+			Tok: token.VAR,
+			Specs: []ast.Spec{
+				&ast.ValueSpec{
+					Names: []*ast.Ident{name},
+					Type:  nativeType,
+				},
+			},
+		},
+	})
+
+	for _, field := range structType.Fields.List {
+		for _, fieldName := range field.Names {
+			var jsArg ast.Expr = &ast.IndexExpr{
+				X: &ast.Ident{Name: "args"},
+				Index: &ast.BasicLit{
+					Kind:  token.INT,
+					Value: strconv.Itoa(argIdx),
+				},
+			}
+			cond := &ast.BinaryExpr{
+				X: &ast.BinaryExpr{
+					X: &ast.CallExpr{
+						Fun:  &ast.Ident{Name: "len"},
+						Args: []ast.Expr{&ast.Ident{Name: "args"}},
+					},
+					Op: token.GTR,
+					Y:  &ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(argIdx)},
+				},
+				Op: token.LAND,
+				Y:  isPresentExpr(jsArg),
+			}
+
+			var fieldResolver []ast.Stmt
+			if gen.config.Async {
+				awaited := &ast.Ident{Name: name.Name + fieldName.Name + "Awaited"}
+				gen.useHelper("await")
+				fieldResolver = append(fieldResolver, &ast.AssignStmt{
+					Lhs: []ast.Expr{awaited},
+					Tok: token.DEFINE,
+					Rhs: []ast.Expr{
+						&ast.CallExpr{
+							Fun:  &ast.Ident{Name: "wasmAwaitArg"},
+							Args: []ast.Expr{jsArg},
+						},
+					},
+				})
+
+				jsArg = awaited
+			}
+
+			fieldDst := &ast.SelectorExpr{X: name, Sel: fieldName}
+			if starType, ok := field.Type.(*ast.StarExpr); ok {
+				// present pointer fields point at their resolved value
+				value := &ast.Ident{Name: name.Name + fieldName.Name}
+				_, valueResolver, err := gen.ResolveValue(value, jsArg, starType.X, value)
+				if err != nil {
+					return nil, nil, fmt.Errorf("Unresolved option %s type %v: %v", fieldName.Name, field.Type, err)
+				}
+
+				fieldResolver = append(fieldResolver, &ast.DeclStmt{
+					Decl: &ast.GenDecl{
+						Tok: token.VAR,
+						Specs: []ast.Spec{
+							&ast.ValueSpec{
+								Names: []*ast.Ident{value},
+								Type:  starType.X,
+							},
+						},
+					},
+				})
+				fieldResolver = append(fieldResolver, valueResolver...)
+				fieldResolver = append(fieldResolver, &ast.AssignStmt{
+					Lhs: []ast.Expr{fieldDst},
+					Tok: token.ASSIGN,
+					Rhs: []ast.Expr{&ast.UnaryExpr{Op: token.AND, X: value}},
+				})
+			} else {
+				_, valueResolver, err := gen.ResolveValue(
+					&ast.Ident{Name: name.Name + fieldName.Name},
+					jsArg,
+					field.Type,
+					fieldDst,
+				)
+				if err != nil {
+					return nil, nil, fmt.Errorf("Unresolved option %s type %v: %v", fieldName.Name, field.Type, err)
+				}
+
+				fieldResolver = append(fieldResolver, valueResolver...)
+			}
+
+			resolver = append(resolver, &ast.IfStmt{
+				Cond: cond,
+				Body: &ast.BlockStmt{List: fieldResolver},
+			})
+			argIdx++
+		}
+	}
+
+	return name, resolver, err
+}
+
+// resolves a []bool parameter described by a
+// 	//wasm:bitmask=n param
+// directive from a js integer, with one bool per bit up to n bits
+//
+// generated resolver:
+// 	nameMask := jsValue.Int()
+// 	name := make([]bool, n)
+// 	for nameIdx := range name {
+// 		name[nameIdx] = nameMask&(1<<nameIdx) != 0
+// 	}
+func (gen *generator) resolveBitmask(
+	name *ast.Ident,
+	jsValue ast.Expr,
+	nativeType ast.Expr,
+	bitmask *directive,
+) (expr ast.Expr, resolver []ast.Stmt, err error) {
+	if types.ExprString(gen.underlyingType(nativeType)) != "[]bool" {
+		return nil, nil, fmt.Errorf("//wasm:bitmask requires a []bool parameter")
+	}
+
+	// js numbers hold integers exactly up to 53 bits
+	width, err := strconv.Atoi(bitmask.value)
+	if err != nil || width < 1 || width > 53 {
+		return nil, nil, fmt.Errorf("//wasm:bitmask width must be between 1 and 53, got \"%s\"", bitmask.value)
+	}
+
+	mask := &ast.Ident{Name: name.Name + "Mask"}
+	idx := &ast.Ident{Name: name.Name + "Idx"}
+	return name, gen.withResolverCount("bitmask", []ast.Stmt{
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{mask},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X:   jsValue,
+						Sel: &ast.Ident{Name: "Int"},
+					},
+				},
+			},
+		},
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{name},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun: &ast.Ident{Name: "make"},
+					Args: []ast.Expr{
+						nativeType,
+						&ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(width)},
+					},
+				},
+			},
+		},
+		&ast.RangeStmt{
+			Key: idx,
+			Tok: token.DEFINE,
+			X:   name,
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					&ast.AssignStmt{
+						Lhs: []ast.Expr{&ast.IndexExpr{X: name, Index: idx}},
+						Tok: token.ASSIGN,
+						Rhs: []ast.Expr{
+							&ast.BinaryExpr{
+								X: &ast.BinaryExpr{
+									X:  mask,
+									Op: token.AND,
+									Y: &ast.ParenExpr{
+										X: &ast.BinaryExpr{
+											X:  &ast.BasicLit{Kind: token.INT, Value: "1"},
+											Op: token.SHL,
+											Y:  idx,
+										},
+									},
+								},
+								Op: token.NEQ,
+								Y:  &ast.BasicLit{Kind: token.INT, Value: "0"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}), err
+}
+
+// js types a //wasm:jstype variant can be selected by, mapped to their syscall/js Type constant.
+// "array" is matched with Array.isArray ahead of the other types
+var jsTypeConsts = map[string]string{
+	"undefined": "TypeUndefined",
+	"null":      "TypeNull",
+	"boolean":   "TypeBoolean",
+	"number":    "TypeNumber",
+	"string":    "TypeString",
+	"symbol":    "TypeSymbol",
+	"object":    "TypeObject",
+	"function":  "TypeFunction",
+}
+
+// resolves an interface parameter described by a
+// 	//wasm:jstype param jstype:Type...
+// directive into the variant type selected by the runtime type of the js value
+// (e.g. string:string array:[]string)
+//
+// generated resolver:
+// 	var name T
+// 	switch {
+// 	case js.Global().Get("Array").Call("isArray", jsValue).Bool():
+// 		...
+// 		name = nameArray
+// 	case jsValue.Type() == js.TypeString:
+// 		...
+// 		name = nameString
+// 	default:
+// 		panic(js.Global().Get("Error").New(...))
+// 	}
+func (gen *generator) resolveJSType(
+	name *ast.Ident,
+	jsValue ast.Expr,
+	nativeType ast.Expr,
+	jsType *directive,
+) (expr ast.Expr, resolver []ast.Stmt, err error) {
+	var arrayCases, cases []ast.Stmt
+	for _, variant := range jsType.args {
+		kind, typeSrc, ok := strings.Cut(variant, ":")
+		if !ok {
+			continue // the parameter name
+		}
+
+		variantType, err := parser.ParseExpr(typeSrc)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Malformed jstype variant %s: %v", variant, err)
+		}
+		clearPositions(variantType)
+
+		variantName := &ast.Ident{Name: name.Name + strings.ToUpper(kind[:1]) + kind[1:]}
+		variantExpr, variantResolver, err := gen.ResolveValue(variantName, jsValue, variantType, nil)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Unresolved jstype variant %s: %v", typeSrc, err)
+		}
+
+		clause := &ast.CaseClause{
+			Body: append(variantResolver, &ast.AssignStmt{
+				Lhs: []ast.Expr{name},
+				Tok: token.ASSIGN,
+				Rhs: []ast.Expr{variantExpr},
+			}),
+		}
+
+		if kind == "array" {
+			clause.List = []ast.Expr{
+				&ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X: &ast.CallExpr{
+							Fun: &ast.SelectorExpr{
+								X: &ast.CallExpr{
+									Fun: &ast.SelectorExpr{
+										X: &ast.CallExpr{
+											Fun: &ast.SelectorExpr{
+												X:   gen.jsIdent(),
+												Sel: &ast.Ident{Name: "Global"},
+											},
+										},
+										Sel: &ast.Ident{Name: "Get"},
+									},
+									Args: []ast.Expr{&ast.BasicLit{Kind: token.STRING, Value: `"Array"`}},
+								},
+								Sel: &ast.Ident{Name: "Call"},
+							},
+							Args: []ast.Expr{
+								&ast.BasicLit{Kind: token.STRING, Value: `"isArray"`},
+								jsValue,
+							},
+						},
+						Sel: &ast.Ident{Name: "Bool"},
+					},
+				},
+			}
+			arrayCases = append(arrayCases, clause)
+			continue
+		}
+
+		typeConst, ok := jsTypeConsts[kind]
+		if !ok {
+			return nil, nil, fmt.Errorf("Unknown js type \"%s\" in jstype variant %s", kind, variant)
+		}
+
+		clause.List = []ast.Expr{
+			&ast.BinaryExpr{
+				X: &ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X:   jsValue,
+						Sel: &ast.Ident{Name: "Type"},
+					},
+				},
+				Op: token.EQL,
+				Y: &ast.SelectorExpr{
+					X:   gen.jsIdent(),
+					Sel: &ast.Ident{Name: typeConst},
+				},
+			},
+		}
+		cases = append(cases, clause)
+	}
+
+	cases = append(append(arrayCases, cases...), &ast.CaseClause{
+		Body: []ast.Stmt{
+			gen.throwStmt(&ast.BasicLit{
+				Kind:  token.STRING,
+				Value: strconv.Quote("Unsupported js type for " + name.Name),
+			}),
+		},
+	})
+
+	return name, []ast.Stmt{
+		&ast.DeclStmt{
+			Decl: &ast.GenDecl{
+				Tok: token.VAR,
+				Specs: []ast.Spec{
+					&ast.ValueSpec{
+						Names: []*ast.Ident{name},
+						Type:  nativeType,
+					},
+				},
+			},
+		},
+		&ast.SwitchStmt{
+			Body: &ast.BlockStmt{List: cases},
+		},
+	}, err
+}
+
+// resolves a matrix parameter described by a
+// 	//wasm:reshape=RxC param
+// directive from a flat js array of R*C elements in row-major order
+//
+// generated resolver:
+// 	if jsValue.Length() != R*C {
+// 		panic(js.Global().Get("Error").New(...))
+// 	}
+// 	var name [R][C]T
+// 	for nameIdx := 0; nameIdx < R*C; nameIdx++ {
+// 		...
+// 		name[nameIdx/C][nameIdx%C] = ...
+// 	}
+func (gen *generator) resolveReshape(
+	name *ast.Ident,
+	jsValue ast.Expr,
+	nativeType ast.Expr,
+	reshape *directive,
+) (expr ast.Expr, resolver []ast.Stmt, err error) {
+	rowsSrc, colsSrc, _ := strings.Cut(reshape.value, "x")
+	rows, rowsErr := strconv.Atoi(rowsSrc)
+	cols, colsErr := strconv.Atoi(colsSrc)
+	if rowsErr != nil || colsErr != nil || rows < 1 || cols < 1 {
+		return nil, nil, fmt.Errorf("//wasm:reshape requires a RxC shape, got \"%s\"", reshape.value)
+	}
+
+	rowType, ok := gen.underlyingType(nativeType).(*ast.ArrayType)
+	if !ok || rowType.Len == nil {
+		return nil, nil, fmt.Errorf("//wasm:reshape requires an array of arrays parameter")
+	}
+
+	colType, ok := rowType.Elt.(*ast.ArrayType)
+	if !ok || colType.Len == nil {
+		return nil, nil, fmt.Errorf("//wasm:reshape requires an array of arrays parameter")
+	}
+
+	if types.ExprString(rowType.Len) != rowsSrc || types.ExprString(colType.Len) != colsSrc {
+		return nil, nil, fmt.Errorf("//wasm:reshape=%s doesn't match parameter type %s", reshape.value, types.ExprString(nativeType))
+	}
+
+	size := &ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(rows * cols)}
+	colsLit := &ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(cols)}
+	idx := &ast.Ident{Name: name.Name + "Idx"}
+	_, eltResolver, err := gen.ResolveValue(
+		&ast.Ident{Name: name.Name + "Elt"},
+		&ast.CallExpr{
+			Fun: &ast.SelectorExpr{
+				X:   jsValue,
+				Sel: &ast.Ident{Name: "Index"},
+			},
+			Args: []ast.Expr{idx},
+		},
+		colType.Elt,
+		&ast.IndexExpr{
+			X: &ast.IndexExpr{
+				X:     name,
+				Index: &ast.BinaryExpr{X: idx, Op: token.QUO, Y: colsLit},
+			},
+			Index: &ast.BinaryExpr{X: idx, Op: token.REM, Y: colsLit},
+		},
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Unresolved matrix element type %v: %v", colType.Elt, err)
+	}
+
+	return name, gen.withResolverCount("reshape", []ast.Stmt{
+		&ast.IfStmt{
+			Cond: &ast.BinaryExpr{
+				X: &ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X:   jsValue,
+						Sel: &ast.Ident{Name: "Length"},
+					},
+				},
+				Op: token.NEQ,
+				Y:  size,
+			},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					gen.throwStmt(&ast.BasicLit{
+						Kind:  token.STRING,
+						Value: strconv.Quote(fmt.Sprintf("Expected %d values for %s", rows*cols, name.Name)),
+					}),
+				},
+			},
+		},
+		&ast.DeclStmt{
+			Decl: &ast.GenDecl{
+				Tok: token.VAR,
+				Specs: []ast.Spec{
+					&ast.ValueSpec{
+						Names: []*ast.Ident{name},
+						Type:  nativeType,
+					},
+				},
+			},
+		},
+		&ast.ForStmt{
+			Init: &ast.AssignStmt{
+				Lhs: []ast.Expr{idx},
+				Tok: token.DEFINE,
+				Rhs: []ast.Expr{&ast.BasicLit{Kind: token.INT, Value: "0"}},
+			},
+			Cond: &ast.BinaryExpr{X: idx, Op: token.LSS, Y: size},
+			Post: &ast.IncDecStmt{X: idx, Tok: token.INC},
+			Body: &ast.BlockStmt{List: eltResolver},
+		},
+	}), err
+}
+
+// resolves a slice parameter described by a
+// 	//wasm:indexmap param
+// directive from a js object keyed by index, up to its largest index.
+// missing indexes are left zero
+//
+// generated resolver:
+// 	nameKeys, nameLen := wasmIndexKeys(jsValue)
+// 	name := make([]T, nameLen)
+// 	for _, nameKey := range nameKeys {
+// 		...
+// 		name[nameKey] = ...
+// 	}
+func (gen *generator) resolveIndexMap(
+	name *ast.Ident,
+	jsValue ast.Expr,
+	nativeType ast.Expr,
+) (expr ast.Expr, resolver []ast.Stmt, err error) {
+	sliceType, ok := gen.underlyingType(nativeType).(*ast.ArrayType)
+	if !ok || sliceType.Len != nil {
+		return nil, nil, fmt.Errorf("//wasm:indexmap requires a slice parameter")
+	}
+
+	keys := &ast.Ident{Name: name.Name + "Keys"}
+	length := &ast.Ident{Name: name.Name + "Len"}
+	key := &ast.Ident{Name: name.Name + "Key"}
+	_, eltResolver, err := gen.ResolveValue(
+		&ast.Ident{Name: name.Name + "Elt"},
+		&ast.CallExpr{
+			Fun: &ast.SelectorExpr{
+				X:   jsValue,
+				Sel: &ast.Ident{Name: "Index"},
+			},
+			Args: []ast.Expr{key},
+		},
+		sliceType.Elt,
+		&ast.IndexExpr{X: name, Index: key},
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Unresolved slice element type %v: %v", sliceType.Elt, err)
+	}
+
+	gen.useHelper("indexmap")
+	return name, gen.withResolverCount("indexmap", []ast.Stmt{
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{keys, length},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun:  &ast.Ident{Name: "wasmIndexKeys"},
+					Args: []ast.Expr{jsValue},
+				},
+			},
+		},
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{name},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun:  &ast.Ident{Name: "make"},
+					Args: []ast.Expr{nativeType, length},
+				},
+			},
+		},
+		&ast.RangeStmt{
+			Key:   &ast.Ident{Name: "_"},
+			Value: key,
+			Tok:   token.DEFINE,
+			X:     keys,
+			Body:  &ast.BlockStmt{List: eltResolver},
+		},
+	}), err
+}
+
+// resolves a float parameter listed in a
+// 	//wasm:nullAsNaN param
+// directive to NaN when the js value is null, rather than zero
+//
+// generated resolver:
+// 	name := T(math.NaN())
+// 	if !jsValue.IsNull() {
+// 		name = ...
+// 	}
+func (gen *generator) resolveNullAsNaN(
+	name *ast.Ident,
+	jsValue ast.Expr,
+	nativeType ast.Expr,
+) (expr ast.Expr, resolver []ast.Stmt, err error) {
+	if float, ok := gen.underlyingType(nativeType).(*ast.Ident); !ok || (float.Name != "float32" && float.Name != "float64") {
+		return nil, nil, fmt.Errorf("//wasm:nullAsNaN requires a float parameter")
+	}
+
+	_, floatResolver, err := gen.ResolveValue(name, jsValue, nativeType, name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	gen.imports["math"] = true
+	var nan ast.Expr = &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   &ast.Ident{Name: "math"},
+			Sel: &ast.Ident{Name: "NaN"},
+		},
+	}
+	if types.ExprString(nativeType) != "float64" {
+		nan = &ast.CallExpr{Fun: nativeType, Args: []ast.Expr{nan}}
+	}
+
+	return name, gen.withResolverCount("nullAsNaN", []ast.Stmt{
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{name},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{nan},
+		},
+		&ast.IfStmt{
+			Cond: &ast.UnaryExpr{
+				Op: token.NOT,
+				X: &ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X:   jsValue,
+						Sel: &ast.Ident{Name: "IsNull"},
+					},
+				},
+			},
+			Body: &ast.BlockStmt{List: floatResolver},
+		},
+	}), err
+}
+
+// resolves a slice or array parameter described by a
+// 	//wasm:validate=func param
+// directive, checking each element with the package's func(T) error as it's resolved.
+// resolving throws with the index of the first invalid element
+func (gen *generator) resolveValidated(
+	name *ast.Ident,
+	jsValue ast.Expr,
+	nativeType ast.Expr,
+	validate *directive,
+) (expr ast.Expr, resolver []ast.Stmt, err error) {
+	if validate.value == "" {
+		return nil, nil, fmt.Errorf("//wasm:validate requires a validator func")
+	}
+
+	if _, ok := gen.underlyingType(nativeType).(*ast.ArrayType); !ok {
+		return nil, nil, fmt.Errorf("//wasm:validate requires a slice or array parameter")
+	}
+
+	gen.eltValidator = &ast.Ident{Name: validate.value}
+	defer func() { gen.eltValidator = nil }()
+
+	return gen.ResolveValue(name, jsValue, nativeType, nil)
+}
+
+// resolves a variadic parameter from the js arguments following the fixed ones,
+// each resolved as the element type. the call passes it on with ...
+//
+// generated resolver:
+// 	var name []T
+// 	for nameIdx := i; nameIdx < len(args); nameIdx++ {
+// 		...
+// 		name = append(name, nameElt)
+// 	}
+func (gen *generator) resolveVariadic(
+	name *ast.Ident,
+	i int,
+	nativeType *ast.Ellipsis,
+) (expr ast.Expr, resolver []ast.Stmt, err error) {
+	idx := &ast.Ident{Name: name.Name + "Idx"}
+	var jsElt ast.Expr = &ast.IndexExpr{
+		X:     &ast.Ident{Name: "args"},
+		Index: idx,
+	}
+
+	var eltResolver []ast.Stmt
+	if gen.config.Async {
+		// 	nameAwaited := wasmAwaitArg(args[nameIdx])
+		awaited := &ast.Ident{Name: name.Name + "Awaited"}
+		gen.useHelper("await")
+		eltResolver = append(eltResolver, &ast.AssignStmt{
+			Lhs: []ast.Expr{awaited},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun:  &ast.Ident{Name: "wasmAwaitArg"},
+					Args: []ast.Expr{jsElt},
+				},
+			},
+		})
+
+		jsElt = awaited
+	}
+
+	eltExpr, resolver, err := gen.ResolveValue(&ast.Ident{Name: name.Name + "Elt"}, jsElt, nativeType.Elt, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	eltResolver = append(eltResolver, resolver...)
+	eltResolver = append(eltResolver, &ast.AssignStmt{
+		Lhs: []ast.Expr{name},
+		Tok: token.ASSIGN,
+		Rhs: []ast.Expr{
+			&ast.CallExpr{
+				Fun:  &ast.Ident{Name: "append"},
+				Args: []ast.Expr{name, eltExpr},
+			},
+		},
+	})
+
+	return name, gen.withResolverCount("variadic", []ast.Stmt{
+		&ast.DeclStmt{
+			Decl: &ast.GenDecl{
+				Tok: token.VAR,
+				Specs: []ast.Spec{
+					&ast.ValueSpec{
+						Names: []*ast.Ident{name},
+						Type:  &ast.ArrayType{Elt: nativeType.Elt},
+					},
+				},
+			},
+		},
+		&ast.ForStmt{
+			Init: &ast.AssignStmt{
+				Lhs: []ast.Expr{idx},
+				Tok: token.DEFINE,
+				Rhs: []ast.Expr{&ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(i)}},
+			},
+			Cond: &ast.BinaryExpr{
+				X:  idx,
+				Op: token.LSS,
+				Y:  &ast.CallExpr{Fun: &ast.Ident{Name: "len"}, Args: []ast.Expr{&ast.Ident{Name: "args"}}},
+			},
+			Post: &ast.IncDecStmt{X: idx, Tok: token.INC},
+			Body: &ast.BlockStmt{List: eltResolver},
+		},
+	}), nil
+}
+
+// resolves a slice parameter described by a
+// 	//wasm:subarray=offset:length param
+// directive from the window of the js array or typed array starting at the offset parameter's index
+// and holding the length parameter's count of elements, or running to the end when length is omitted.
+// typed arrays are windowed with subarray, so only the window is copied
+//
+// generated resolver:
+// 	nameWindow := wasmSubarray(jsValue, args[offset], args[length])
+// 	...
+func (gen *generator) resolveSubarray(
+	name *ast.Ident,
+	jsValue ast.Expr,
+	nativeType ast.Expr,
+	subarray *directive,
+	params *ast.FieldList,
+) (expr ast.Expr, resolver []ast.Stmt, err error) {
+	if array, ok := gen.underlyingType(nativeType).(*ast.ArrayType); !ok || array.Len != nil {
+		return nil, nil, fmt.Errorf("//wasm:subarray requires a slice parameter")
+	}
+
+	offset, length, _ := strings.Cut(subarray.value, ":")
+	if offset == "" {
+		return nil, nil, fmt.Errorf("//wasm:subarray requires an offset parameter, e.g. //wasm:subarray=offset:length %s", name)
+	}
+
+	// the window bounds are read from the js arguments of the named parameters
+	bounds := make([]ast.Expr, 0, 2)
+	for _, bound := range []string{offset, length} {
+		if bound == "" {
+			bounds = append(bounds, &ast.CallExpr{
+				Fun: &ast.SelectorExpr{
+					X:   gen.jsIdent(),
+					Sel: &ast.Ident{Name: "Undefined"},
+				},
+			})
+			continue
+		}
+
+		i := paramIndex(params, bound)
+		if i < 0 {
+			return nil, nil, fmt.Errorf("No //wasm:subarray bound parameter \"%s\" found", bound)
+		}
+
+		bounds = append(bounds, &ast.IndexExpr{
+			X:     &ast.Ident{Name: "args"},
+			Index: &ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(i)},
+		})
+	}
+
+	window := &ast.Ident{Name: name.Name + "Window"}
+	gen.useHelper("subarray")
+	expr, resolver, err = gen.ResolveValue(name, window, nativeType, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return expr, append([]ast.Stmt{
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{window},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun:  &ast.Ident{Name: "wasmSubarray"},
+					Args: append([]ast.Expr{jsValue}, bounds...),
+				},
+			},
+		},
+	}, resolver...), nil
+}
+
+// resolves a slice of structs parameter described by a
+// 	//wasm:uniqueBy=Field param
+// directive, dropping elements whose Field equals that of an earlier element
+//
+// generated resolver:
+// 	...
+// 	nameSeen := make(map[F]bool, len(name))
+// 	nameUnique := name[:0]
+// 	for _, nameElt := range name {
+// 		if !nameSeen[nameElt.Field] {
+// 			nameSeen[nameElt.Field] = true
+// 			nameUnique = append(nameUnique, nameElt)
+// 		}
+// 	}
+func (gen *generator) resolveUniqueBy(
+	name *ast.Ident,
+	jsValue ast.Expr,
+	nativeType ast.Expr,
+	uniqueBy *directive,
+) (expr ast.Expr, resolver []ast.Stmt, err error) {
+	array, ok := gen.underlyingType(nativeType).(*ast.ArrayType)
+	if !ok || array.Len != nil {
+		return nil, nil, fmt.Errorf("//wasm:uniqueBy requires a slice parameter")
+	}
+
+	structType, ok := gen.underlyingType(array.Elt).(*ast.StructType)
+	if !ok {
+		return nil, nil, fmt.Errorf("//wasm:uniqueBy requires a slice of structs")
+	}
+
+	var keyType ast.Expr
+	for _, field := range structType.Fields.List {
+		for _, fieldName := range field.Names {
+			if fieldName.Name == uniqueBy.value {
+				keyType = field.Type
+			}
+		}
+	}
+
+	if keyType == nil {
+		return nil, nil, fmt.Errorf("No //wasm:uniqueBy field \"%s\" found in %s", uniqueBy.value, types.ExprString(array.Elt))
+	}
+
+	// slices, maps and funcs can't be map keys
+	switch underlying := gen.underlyingType(keyType).(type) {
+	case *ast.MapType, *ast.FuncType:
+		return nil, nil, fmt.Errorf("//wasm:uniqueBy field \"%s\" isn't comparable", uniqueBy.value)
+	case *ast.ArrayType:
+		if underlying.Len == nil {
+			return nil, nil, fmt.Errorf("//wasm:uniqueBy field \"%s\" isn't comparable", uniqueBy.value)
+		}
+	}
+
+	expr, resolver, err = gen.ResolveValue(name, jsValue, nativeType, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	seen := &ast.Ident{Name: name.Name + "Seen"}
+	unique := &ast.Ident{Name: name.Name + "Unique"}
+	elt := &ast.Ident{Name: name.Name + "Elt"}
+	key := &ast.IndexExpr{
+		X:     seen,
+		Index: &ast.SelectorExpr{X: elt, Sel: &ast.Ident{Name: uniqueBy.value}},
+	}
+
+	return unique, append(resolver,
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{seen},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun: &ast.Ident{Name: "make"},
+					Args: []ast.Expr{
+						&ast.MapType{Key: keyType, Value: &ast.Ident{Name: "bool"}},
+						&ast.CallExpr{Fun: &ast.Ident{Name: "len"}, Args: []ast.Expr{expr}},
+					},
+				},
+			},
+		},
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{unique},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.SliceExpr{
+					X:    expr,
+					High: &ast.BasicLit{Kind: token.INT, Value: "0"},
+				},
+			},
+		},
+		&ast.RangeStmt{
+			Key:   &ast.Ident{Name: "_"},
+			Value: elt,
+			Tok:   token.DEFINE,
+			X:     expr,
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					&ast.IfStmt{
+						Cond: &ast.UnaryExpr{Op: token.NOT, X: key},
+						Body: &ast.BlockStmt{
+							List: []ast.Stmt{
+								&ast.AssignStmt{
+									Lhs: []ast.Expr{key},
+									Tok: token.ASSIGN,
+									Rhs: []ast.Expr{&ast.Ident{Name: "true"}},
+								},
+								&ast.AssignStmt{
+									Lhs: []ast.Expr{unique},
+									Tok: token.ASSIGN,
+									Rhs: []ast.Expr{
+										&ast.CallExpr{
+											Fun:  &ast.Ident{Name: "append"},
+											Args: []ast.Expr{unique, elt},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	), nil
+}
+
+// resolves a slice of structs parameter described by a
+// 	//wasm:columnar param
+// directive from a js object holding an array per field, keyed by the fields' property names
+// (e.g. {Name: ["a", "b"], Age: [1, 2]}), zipping the arrays into elements by index.
+// fields whose array is absent are left zero, arrays of differing lengths throw
+//
+// generated resolver:
+// 	nameColumns, nameLen := wasmColumns(jsValue, "Name", "Age")
+// 	name := make([]T, nameLen)
+// 	for nameIdx := 0; nameIdx < nameLen; nameIdx++ {
+// 		if !nameColumns[0].IsUndefined() {
+// 			name[nameIdx].Name = nameColumns[0].Index(nameIdx).String()
+// 		}
+// 		...
+// 	}
+func (gen *generator) resolveColumnar(
+	name *ast.Ident,
+	jsValue ast.Expr,
+	nativeType ast.Expr,
+) (expr ast.Expr, resolver []ast.Stmt, err error) {
+	array, ok := gen.underlyingType(nativeType).(*ast.ArrayType)
+	if !ok || array.Len != nil {
+		return nil, nil, fmt.Errorf("//wasm:columnar requires a slice parameter")
+	}
+
+	structType, ok := gen.underlyingType(array.Elt).(*ast.StructType)
+	if !ok {
+		return nil, nil, fmt.Errorf("//wasm:columnar requires a slice of structs")
+	}
+
+	columns := &ast.Ident{Name: name.Name + "Columns"}
+	length := &ast.Ident{Name: name.Name + "Len"}
+	idx := &ast.Ident{Name: name.Name + "Idx"}
+	keys := []ast.Expr{jsValue}
+	var eltResolver []ast.Stmt
+	for _, field := range structType.Fields.List {
+		tag, err := parseFieldTag(field)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, fieldName := range field.Names {
+			if _, ok := tag.options["computed"]; ok || tag.name == "-" || !fieldName.IsExported() {
+				continue
+			}
+
+			column := &ast.IndexExpr{
+				X:     columns,
+				Index: &ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(len(keys) - 1)},
+			}
+			keys = append(keys, &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(gen.propertyName(tag, fieldName))})
+
+			_, fieldResolver, err := gen.ResolveValue(
+				&ast.Ident{Name: name.Name + fieldName.Name},
+				&ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X:   column,
+						Sel: &ast.Ident{Name: "Index"},
+					},
+					Args: []ast.Expr{idx},
+				},
+				field.Type,
+				&ast.SelectorExpr{
+					X:   &ast.IndexExpr{X: name, Index: idx},
+					Sel: &ast.Ident{Name: fieldName.Name},
+				},
+			)
+			if err != nil {
+				return nil, nil, fmt.Errorf("Unresolved struct field type %v: %v", types.ExprString(field.Type), err)
+			}
+
+			eltResolver = append(eltResolver, &ast.IfStmt{
+				Cond: &ast.UnaryExpr{
+					Op: token.NOT,
+					X: &ast.CallExpr{
+						Fun: &ast.SelectorExpr{
+							X:   column,
+							Sel: &ast.Ident{Name: "IsUndefined"},
+						},
+					},
+				},
+				Body: &ast.BlockStmt{List: fieldResolver},
+			})
+		}
+	}
+
+	gen.useHelper("columns")
+	return name, gen.withResolverCount("columnar", []ast.Stmt{
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{columns, length},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun:  &ast.Ident{Name: "wasmColumns"},
+					Args: keys,
+				},
+			},
+		},
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{name},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun:  &ast.Ident{Name: "make"},
+					Args: []ast.Expr{nativeType, length},
+				},
+			},
+		},
+		&ast.ForStmt{
+			Init: &ast.AssignStmt{
+				Lhs: []ast.Expr{idx},
+				Tok: token.DEFINE,
+				Rhs: []ast.Expr{&ast.BasicLit{Kind: token.INT, Value: "0"}},
+			},
+			Cond: &ast.BinaryExpr{X: idx, Op: token.LSS, Y: length},
+			Post: &ast.IncDecStmt{X: idx, Tok: token.INC},
+			Body: &ast.BlockStmt{List: eltResolver},
+		},
+	}), nil
+}
+
+// resolves a slice parameter described by a
+// 	//wasm:split=; param
+// directive from a js string split on the delimiter (a comma when omitted), e.g. "1;2;3" into []int.
+// parts are converted to numbers for numeric element types, arrays are resolved as they are
+//
+// generated resolver:
+// 	nameParts := wasmSplitParts(jsValue, ";", true)
+// 	...
+func (gen *generator) resolveSplit(
+	name *ast.Ident,
+	jsValue ast.Expr,
+	nativeType ast.Expr,
+	split *directive,
+) (expr ast.Expr, resolver []ast.Stmt, err error) {
+	array, ok := gen.underlyingType(nativeType).(*ast.ArrayType)
+	if !ok || array.Len != nil {
+		return nil, nil, fmt.Errorf("//wasm:split requires a slice parameter")
+	}
+
+	delimiter := split.value
+	if delimiter == "" {
+		delimiter = ","
+	}
+
+	elt, _ := gen.underlyingType(array.Elt).(*ast.Ident)
+	numeric := "true"
+	switch {
+	case elt == nil:
+		return nil, nil, fmt.Errorf("//wasm:split requires string or numeric elements, got %s", types.ExprString(array.Elt))
+	case elt.Name == "string":
+		numeric = "false"
+	case elt.Name == "bool" || elt.Name == "any" || strings.HasPrefix(elt.Name, "complex"):
+		return nil, nil, fmt.Errorf("//wasm:split requires string or numeric elements, got %s", elt.Name)
+	}
+
+	parts := &ast.Ident{Name: name.Name + "Parts"}
+	gen.useHelper("split")
+	expr, resolver, err = gen.ResolveValue(name, parts, nativeType, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return expr, append([]ast.Stmt{
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{parts},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun: &ast.Ident{Name: "wasmSplitParts"},
+					Args: []ast.Expr{
+						jsValue,
+						&ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(delimiter)},
+						&ast.Ident{Name: numeric},
+					},
+				},
+			},
+		},
+	}, resolver...), nil
+}
+
+// resolves a []byte parameter described by a
+// 	//wasm:hex param
+// directive from a hex string, throwing when it isn't valid hex
+//
+// generated resolver:
+// 	name, nameErr := hex.DecodeString(jsValue.String())
+// 	if nameErr != nil {
+// 		panic(js.Global().Get("Error").New(nameErr.Error()))
+// 	}
+func (gen *generator) resolveHex(
+	name *ast.Ident,
+	jsValue ast.Expr,
+	nativeType ast.Expr,
+) (expr ast.Expr, resolver []ast.Stmt, err error) {
+	if array, ok := gen.underlyingType(nativeType).(*ast.ArrayType); !ok || array.Len != nil || !isByte(array.Elt) {
+		return nil, nil, fmt.Errorf("//wasm:hex requires a []byte parameter")
+	}
+
+	gen.imports["encoding/hex"] = true
+	errIdent := &ast.Ident{Name: name.Name + "Err"}
+	return name, gen.withResolverCount("hex", []ast.Stmt{
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{name, errIdent},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X:   &ast.Ident{Name: "hex"},
+						Sel: &ast.Ident{Name: "DecodeString"},
+					},
+					Args: []ast.Expr{
+						&ast.CallExpr{
+							Fun: &ast.SelectorExpr{
+								X:   jsValue,
+								Sel: &ast.Ident{Name: "String"},
+							},
+						},
+					},
+				},
+			},
+		},
+		&ast.IfStmt{
+			Cond: &ast.BinaryExpr{X: errIdent, Op: token.NEQ, Y: &ast.Ident{Name: "nil"}},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					gen.throwStmt(&ast.CallExpr{
+						Fun: &ast.SelectorExpr{
+							X:   errIdent,
+							Sel: &ast.Ident{Name: "Error"},
+						},
+					}),
+				},
+			},
+		},
+	}), nil
+}
@@ -0,0 +1,521 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnionVariants(t *testing.T) {
+	src := `package main
+
+type Shape interface {
+	Area() float64
+}
+
+type Square struct {
+	Side float64
+}
+
+func (s *Square) Area() float64 {
+	return s.Side * s.Side
+}
+
+type Circle struct {
+	R float64
+}
+
+func (c Circle) Area() float64 {
+	return 3 * c.R * c.R
+}
+
+//wasm:union=kind s 1:*Square 2:Circle
+func Area(s Shape) float64 {
+	return s.Area()
+}
+`
+	got := runWasm(t, src, nil, `[Area({kind: 1, Side: 2}), Area({kind: 2, R: 1})].join(",")`)
+	if got != "4,3" {
+		t.Errorf("Expected 4,3, got %s", got)
+	}
+
+	// variants that aren't named types, keyed by strings
+	src = `package main
+
+import "fmt"
+
+//wasm:union=kind v ints:[]int strings:[]string
+func Describe(v any) string {
+	return fmt.Sprint(v)
+}
+`
+	got = runWasm(t, src, nil, `[Describe({kind: "ints", length: 2, 0: 1, 1: 2}), Describe({kind: "strings", length: 1, 0: "a"})].join(",")`)
+	if got != "[1 2],[a]" {
+		t.Errorf("Expected [1 2],[a], got %s", got)
+	}
+
+	if got := runWasmThrows(t, src, nil, `Describe({kind: "set"})`); got != "Unknown kind for v" {
+		t.Errorf("Expected Unknown kind for v, got %s", got)
+	}
+}
+
+func TestSchemaValidation(t *testing.T) {
+	schemaPath := filepath.Join(t.TempDir(), "tags.json")
+	schema := `{"type": "array", "maxItems": 2, "items": {"type": "string", "minLength": 1}}`
+	if err := os.WriteFile(schemaPath, []byte(schema), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	src := `package main
+
+import "strings"
+
+//wasm:schema=` + schemaPath + ` tags
+func Join(tags []string) string {
+	return strings.Join(tags, ",")
+}
+`
+	if got := runWasm(t, src, nil, `Join(["a", "b"])`); got != "a,b" {
+		t.Errorf("Expected a,b, got %s", got)
+	}
+
+	got := runWasmThrows(t, src, nil, `Join(["a", "", 1])`)
+	want := "Schema validation failed: tags.length must be at most 2; tags[1].length must be at least 1; tags[2] must be of type string"
+	if got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestOptionsParams(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+type SearchOptions struct {
+	Limit  *int
+	Prefix *string
+	Exact  bool
+}
+
+//wasm:options opts
+func Search(query string, opts SearchOptions) string {
+	limit, prefix := "nil", "nil"
+	if opts.Limit != nil {
+		limit = fmt.Sprint(*opts.Limit)
+	}
+	if opts.Prefix != nil {
+		prefix = *opts.Prefix
+	}
+	return fmt.Sprint(query, " ", limit, " ", prefix, " ", opts.Exact)
+}
+`
+	got := runWasm(t, src, nil, `[Search("q"), Search("q", 5), Search("q", undefined, "p", true), Search("q", null, null)].join(",")`)
+	if want := "q nil nil false,q 5 nil false,q nil p true,q nil nil false"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	_, err := tryGenerate(`package main
+
+type Options struct {
+	Limit *int
+}
+
+//wasm:options opts
+func Search(opts Options, query string) {}
+`, nil)
+	if err == nil || !strings.Contains(err.Error(), "must be the last parameter") {
+		t.Errorf("Expected an error requiring opts to be last, got %v", err)
+	}
+}
+
+func TestBitmaskParams(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+//wasm:bitmask=4 flags
+func Flags(flags []bool) string {
+	return fmt.Sprint(flags)
+}
+`
+	got := runWasm(t, src, nil, `Flags(5) + " " + Flags(0)`)
+	if got != "[true false true false] [false false false false]" {
+		t.Errorf("Expected [true false true false] [false false false false], got %s", got)
+	}
+
+	_, err := tryGenerate(`package main
+
+//wasm:bitmask=64 flags
+func Flags(flags []bool) {}
+`, nil)
+	if err == nil || !strings.Contains(err.Error(), "between 1 and 53") {
+		t.Errorf("Expected an error bounding the width, got %v", err)
+	}
+}
+
+func TestFlattenParams(t *testing.T) {
+	src := `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type Inner struct {
+	B int
+	C int
+}
+
+type Config struct {
+	A Inner
+	D int
+}
+
+//wasm:flatten cfg
+func Expand(cfg json.RawMessage) string {
+	return string(cfg)
+}
+
+//wasm:flatten cfg
+func Sum(cfg Config) string {
+	return fmt.Sprint(cfg.A.B, cfg.A.C, cfg.D)
+}
+`
+	got := runWasm(t, src, nil, `[Expand({"a.b": 1, "a.c": 2, d: 3}), Sum({"A.B": 1, "A.C": 2, D: 3})].join(",")`)
+	if want := `{"a":{"b":1,"c":2},"d":3},1 2 3`; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestJSTypeVariants(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+//wasm:jstype tags string:string array:[]string
+func Tags(tags any) string {
+	return fmt.Sprintf("%T %v", tags, tags)
+}
+`
+	got := runWasm(t, src, nil, `Tags("a") + "," + Tags(["a", "b"])`)
+	if got != "string a,[]string [a b]" {
+		t.Errorf("Expected string a,[]string [a b], got %s", got)
+	}
+
+	if got := runWasmThrows(t, src, nil, `Tags(1)`); got != "Unsupported js type for tags" {
+		t.Errorf("Expected Unsupported js type for tags, got %s", got)
+	}
+}
+
+func TestReshapeParams(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+//wasm:reshape=3x3 m
+func Matrix(m [3][3]float64) string {
+	return fmt.Sprint(m)
+}
+`
+	got := runWasm(t, src, nil, `Matrix([1, 2, 3, 4, 5, 6, 7, 8, 9])`)
+	if got != "[[1 2 3] [4 5 6] [7 8 9]]" {
+		t.Errorf("Expected [[1 2 3] [4 5 6] [7 8 9]], got %s", got)
+	}
+
+	if got := runWasmThrows(t, src, nil, `Matrix([1, 2, 3])`); got != "Expected 9 values for m" {
+		t.Errorf("Expected Expected 9 values for m, got %s", got)
+	}
+
+	_, err := tryGenerate(`package main
+
+//wasm:reshape=2x3 m
+func Matrix(m [3][3]float64) {}
+`, nil)
+	if err == nil || !strings.Contains(err.Error(), "doesn't match parameter type") {
+		t.Errorf("Expected a shape mismatch error, got %v", err)
+	}
+}
+
+func TestIndexMapParams(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+//wasm:indexmap names
+func Names(names []string) string {
+	return fmt.Sprintf("%q", names)
+}
+`
+	got := runWasm(t, src, nil, `Names({0: "a", 2: "c", x: "ignored"}) + " " + Names({})`)
+	if want := `["a" "" "c"] []`; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestNullAsNaNParams(t *testing.T) {
+	src := `package main
+
+import (
+	"fmt"
+	"math"
+)
+
+//wasm:nullAsNaN x
+func Check(x float64, y float32) string {
+	return fmt.Sprint(math.IsNaN(x), " ", x+float64(y))
+}
+`
+	got := runWasm(t, src, nil, `[Check(null, 1), Check(2, 1)].join(",")`)
+	if want := "true NaN,false 3"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	_, err := tryGenerate(strings.Replace(src, "nullAsNaN x", "nullAsNaN y x", 1), nil)
+	if err != nil {
+		t.Errorf("Expected float32 params to be accepted, got %v", err)
+	}
+
+	_, err = tryGenerate(strings.Replace(src, "x float64", "x int", 1), nil)
+	if err == nil {
+		t.Error("Expected an error for a non-float //wasm:nullAsNaN param")
+	}
+}
+
+func TestMaxBytesArgs(t *testing.T) {
+	src := `package main
+
+//wasm:maxbytes=8
+func Join(a string, b []int32) int {
+	return len(a) + len(b)
+}
+`
+	// strings count their length and slices their length times the element size
+	if got := runWasm(t, src, nil, `String(Join("abcd", [1]))`); got != "5" {
+		t.Errorf("Expected 5, got %s", got)
+	}
+
+	if got := runWasmThrows(t, src, nil, `Join("abcd", [1, 2])`); got != "Arguments exceed 8 bytes" {
+		t.Errorf("Expected Arguments exceed 8 bytes, got %s", got)
+	}
+}
+
+func TestValidateElements(t *testing.T) {
+	src := `package main
+
+import "errors"
+
+func positive(n int) error {
+	if n <= 0 {
+		return errors.New("not positive")
+	}
+	return nil
+}
+
+//wasm:validate=positive ns
+func Sum(ns []int) int {
+	total := 0
+	for _, n := range ns {
+		total += n
+	}
+	return total
+}
+`
+	if got := runWasm(t, src, nil, `String(Sum([1, 2, 3]))`); got != "6" {
+		t.Errorf("Expected 6, got %s", got)
+	}
+
+	// resolution stops at the first invalid element
+	if got := runWasmThrows(t, src, nil, `Sum([1, 2, -3, 0])`); got != "Invalid element 2 of ns: not positive" {
+		t.Errorf("Expected the element at index 2 to be invalid, got %s", got)
+	}
+}
+
+func TestVariadicParams(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+func Sum(nums ...int) int {
+	total := 0
+	for _, n := range nums {
+		total += n
+	}
+	return total
+}
+
+func Join(prefix string, nums ...int) string {
+	return fmt.Sprint(prefix, len(nums), nums)
+}
+`
+	got := runWasm(t, src, nil, `[Sum(), Sum(1, 2, 3), Join("none"), Join("some", 4, 5)].join(",")`)
+	if want := "0,6,none0 [],some2 [4 5]"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestSubarrayParams(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+//wasm:subarray=offset:length data
+func Window(data []byte, offset, length int) string {
+	return fmt.Sprint(data)
+}
+
+//wasm:subarray=offset: data
+func Tail(data []int, offset int) string {
+	return fmt.Sprint(data)
+}
+`
+	script := `const bytes = new Uint8Array([1, 2, 3, 4, 5, 6]);
+[Window(bytes, 2, 3), Window(bytes, 0, 0), Tail([7, 8, 9], 1)].join(",")`
+	got := runWasm(t, src, nil, script)
+	if want := "[3 4 5],[],[8 9]"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	got = runWasmThrows(t, src, nil, `Window(new Uint8Array(4), 2, 3)`)
+	if want := "Window [2:5] out of range for length 4"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestUniqueByParams(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+type User struct {
+	ID   int
+	Name string
+}
+
+//wasm:uniqueBy=ID users
+func Names(users []User) string {
+	names := make([]string, len(users))
+	for i, user := range users {
+		names[i] = user.Name
+	}
+	return fmt.Sprint(names)
+}
+`
+	// the first element with each ID is kept
+	got := runWasm(t, src, nil, `Names([{ID: 1, Name: "ann"}, {ID: 2, Name: "bo"}, {ID: 1, Name: "cy"}, {ID: 3, Name: "di"}, {ID: 2, Name: "ed"}])`)
+	if want := "[ann bo di]"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestColumnarParams(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+type Person struct {
+	Name string ` + "`js:\"names\"`" + `
+	Age  int    ` + "`js:\"ages\"`" + `
+}
+
+//wasm:columnar people
+func Describe(people []Person) string {
+	return fmt.Sprint(people)
+}
+`
+	script := `[Describe({names: ["ann", "bo"], ages: [31, 4]}), Describe({}), Describe({names: ["di"]})].join(",")`
+	got := runWasm(t, src, nil, script)
+	if want := "[{ann 31} {bo 4}],[],[{di 0}]"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	got = runWasmThrows(t, src, nil, `Describe({names: ["ann", "bo"], ages: [31]})`)
+	if want := "Column ages differs in length from the columns before it"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestSplitParams(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+//wasm:split=; ids
+func Sum(ids []int) int {
+	total := 0
+	for _, id := range ids {
+		total += id
+	}
+	return total
+}
+
+//wasm:split tags
+func Tags(tags []string) string {
+	return fmt.Sprintf("%q", tags)
+}
+`
+	got := runWasm(t, src, nil, `[Sum("1;2; 30"), Sum(""), Sum([4, 5]), Tags("a,b"), Tags("")].join(",")`)
+	if want := `33,0,9,["a" "b"],[]`; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	if _, err := tryGenerate(`package main
+
+//wasm:split flags
+func Flags(flags []bool) int {
+	return len(flags)
+}
+`, nil); err == nil {
+		t.Errorf("Expected an error splitting into []bool")
+	}
+}
+
+func TestExclusiveParams(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+//wasm:exclusive id,email
+func Find(id *int, email *string, limit int) string {
+	if id != nil {
+		return fmt.Sprint("id ", *id, " ", limit)
+	}
+	if email != nil {
+		return fmt.Sprint("email ", *email, " ", limit)
+	}
+	return fmt.Sprint("all ", limit)
+}
+`
+	got := runWasm(t, src, nil, `[Find(1, null, 5), Find(undefined, "a@b", 5), Find(null, null, 5)].join(",")`)
+	if want := "id 1 5,email a@b 5,all 5"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	if got := runWasmThrows(t, src, nil, `Find(1, "a@b", 5)`); got != "At most one of id, email may be passed" {
+		t.Errorf("Expected At most one of id, email may be passed, got %s", got)
+	}
+
+	if _, err := tryGenerate(strings.Replace(src, "id,email", "id,phone", 1), nil); err == nil {
+		t.Error("Expected an error for an unknown exclusive parameter")
+	}
+}
+
+func TestHexParams(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+//wasm:hex data
+func Bytes(data []byte) string {
+	return fmt.Sprint(data)
+}
+`
+	got := runWasm(t, src, nil, `[Bytes("00ff10Ab"), Bytes("")].join(",")`)
+	if want := "[0 255 16 171],[]"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	if got := runWasmThrows(t, src, nil, `Bytes("zz1")`); got != "encoding/hex: invalid byte: U+007A 'z'" {
+		t.Errorf("Expected encoding/hex: invalid byte: U+007A 'z', got %s", got)
+	}
+}
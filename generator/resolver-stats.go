@@ -0,0 +1,124 @@
+package generator
+
+import (
+	"go/ast"
+	"go/token"
+	"strconv"
+)
+
+// returns a statement incrementing the runtime counter of the given resolver kind,
+// or nil if resolver stats are disabled
+//
+// generated statement:
+// 	atomic.AddInt64(&wasmResolverCounts[i], 1)
+func (gen *generator) countResolver(kind string) ast.Stmt {
+	if !gen.config.ResolverStats {
+		return nil
+	}
+
+	idx, ok := gen.statKinds[kind]
+	if !ok {
+		idx = len(gen.statKinds)
+		gen.statKinds[kind] = idx
+	}
+
+	gen.imports["sync/atomic"] = true
+	return &ast.ExprStmt{
+		X: &ast.CallExpr{
+			Fun: &ast.SelectorExpr{
+				X:   &ast.Ident{Name: "atomic"},
+				Sel: &ast.Ident{Name: "AddInt64"},
+			},
+			Args: []ast.Expr{
+				&ast.UnaryExpr{
+					Op: token.AND,
+					X: &ast.IndexExpr{
+						X:     &ast.Ident{Name: "wasmResolverCounts"},
+						Index: &ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(idx)},
+					},
+				},
+				&ast.BasicLit{Kind: token.INT, Value: "1"},
+			},
+		},
+	}
+}
+
+// prepends the counter of the given resolver kind to resolver when resolver stats are enabled
+func (gen *generator) withResolverCount(kind string, resolver []ast.Stmt) []ast.Stmt {
+	if count := gen.countResolver(kind); count != nil {
+		return append([]ast.Stmt{count}, resolver...)
+	}
+
+	return resolver
+}
+
+// returns the counters incremented by the generated resolvers
+// and a wrapper reporting them to js as an object keyed by resolver kind
+//
+// generated declarations:
+// 	var wasmResolverCounts [n]int64
+//
+// 	func wasmResolverStats(this js.Value, args []js.Value) any {
+// 		return map[string]any{
+// 			"int": atomic.LoadInt64(&wasmResolverCounts[0]),
+// 			...
+// 		}
+// 	}
+func (gen *generator) resolverStatsDecls() []ast.Decl {
+	stats := make([]ast.Expr, len(gen.statKinds))
+	for kind, idx := range gen.statKinds {
+		stats[idx] = &ast.KeyValueExpr{
+			Key: &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(kind)},
+			Value: &ast.CallExpr{
+				Fun: &ast.SelectorExpr{
+					X:   &ast.Ident{Name: "atomic"},
+					Sel: &ast.Ident{Name: "LoadInt64"},
+				},
+				Args: []ast.Expr{
+					&ast.UnaryExpr{
+						Op: token.AND,
+						X: &ast.IndexExpr{
+							X:     &ast.Ident{Name: "wasmResolverCounts"},
+							Index: &ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(idx)},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	gen.imports["sync/atomic"] = true
+	return []ast.Decl{
+		&ast.GenDecl{
+			Tok: token.VAR,
+			Specs: []ast.Spec{
+				&ast.ValueSpec{
+					Names: []*ast.Ident{{Name: "wasmResolverCounts"}},
+					Type: &ast.ArrayType{
+						Len: &ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(len(stats))},
+						Elt: &ast.Ident{Name: "int64"},
+					},
+				},
+			},
+		},
+		&ast.FuncDecl{
+			Name: &ast.Ident{Name: "wasmResolverStats"},
+			Type: gen.wrapperFuncType(),
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					&ast.ReturnStmt{
+						Results: []ast.Expr{
+							&ast.CompositeLit{
+								Type: &ast.MapType{
+									Key:   &ast.Ident{Name: "string"},
+									Value: &ast.Ident{Name: "any"},
+								},
+								Elts: stats,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,22 @@
+package generator
+
+import "testing"
+
+func TestResolverStats(t *testing.T) {
+	src := `package main
+
+func Join(a string, ns []int) int {
+	return len(a) + len(ns)
+}
+
+func ResolverStats(n int) int {
+	return n
+}
+`
+	config := NewConfig()
+	config.ResolverStats = true
+	got := runWasm(t, src, config, `Join("a", [1, 2]); Join("b", [3]); ResolverStats(1); const s = __resolverStats(); Object.keys(s).sort().map((k) => k + "=" + s[k]).join(",")`)
+	if got != "array=2,int=4,string=2" {
+		t.Errorf("Expected array=2,int=4,string=2, got %s", got)
+	}
+}
@@ -0,0 +1,125 @@
+package generator
+
+import (
+	"fmt"
+	"go/ast"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// the parsed value of a `js:"name,option,key=value"` struct field tag,
+// the name may also be given by a `wasm:"name"` tag
+//
+// supported options:
+// 	oneofgroup=group	at most one field of the group may be present
+// 	enum=lookup	numeric values are mapped through the table named lookup
+// 	required	resolving throws when the field is absent
+// 	computed	the field is derived and never resolved from js
+// 	fromThis	the field is resolved from the this value of the call rather than the js value
+type fieldTag struct {
+	name    string
+	options map[string]string
+}
+
+// returns the parsed js tag of the given field,
+// fields without a js tag return an empty tag
+func parseFieldTag(field *ast.Field) (*fieldTag, error) {
+	tag := &fieldTag{options: make(map[string]string)}
+	if field.Tag == nil {
+		return tag, nil
+	}
+
+	rawTag, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return nil, fmt.Errorf("Malformed struct tag %s: %v", field.Tag.Value, err)
+	}
+
+	if jsTag, ok := reflect.StructTag(rawTag).Lookup("js"); ok {
+		tag.parse(jsTag)
+	}
+
+	// a wasm:"name" tag overrides the property name, "-" skips the field,
+	// its options (e.g. wasm:"name,required") adding to those of the js tag
+	if wasmTag, ok := reflect.StructTag(rawTag).Lookup("wasm"); ok {
+		tag.parse(wasmTag)
+	}
+
+	return tag, nil
+}
+
+// sets the name and options of the tag from a name,option,key=value tag value
+func (tag *fieldTag) parse(value string) {
+	parts := strings.Split(value, ",")
+	tag.name = parts[0]
+	for _, option := range parts[1:] {
+		key, value, _ := strings.Cut(option, "=")
+		tag.options[key] = value
+	}
+}
+
+// returns the js property name of the given field,
+// the go field name converted by the configured strategy unless the tag names another
+func (gen *generator) propertyName(tag *fieldTag, fieldName *ast.Ident) string {
+	if tag.name != "" {
+		return tag.name
+	}
+
+	return convertFieldName(fieldName.Name, gen.config.FieldNameStrategy)
+}
+
+// returns the other property names a field is read from with KeyAliasing, tried in order when
+// the property isn't present: the go field name, then its camelCase and snake_case forms.
+// fields named by a tag have no aliases
+func (gen *generator) propertyAliases(tag *fieldTag, fieldName *ast.Ident) []string {
+	if !gen.config.KeyAliasing || tag.name != "" {
+		return nil
+	}
+
+	seen := map[string]bool{gen.propertyName(tag, fieldName): true}
+	aliases := make([]string, 0, 2)
+	for _, strategy := range []FieldNameStrategy{AsIs, CamelCase, SnakeCase} {
+		alias := convertFieldName(fieldName.Name, strategy)
+		if !seen[alias] {
+			seen[alias] = true
+			aliases = append(aliases, alias)
+		}
+	}
+
+	return aliases
+}
+
+// returns the js property name of a field following strategy
+func convertFieldName(name string, strategy FieldNameStrategy) string {
+	switch strategy {
+	case CamelCase:
+		words := splitWords(name)
+		words[0] = strings.ToLower(words[0])
+		return strings.Join(words, "")
+	case SnakeCase:
+		return strings.ToLower(strings.Join(splitWords(name), "_"))
+	default:
+		return name
+	}
+}
+
+// splits a mixed caps name into its words, keeping initialisms whole:
+// 	HTTPStatus	HTTP Status
+// 	UserID	User ID
+func splitWords(name string) []string {
+	runes := []rune(name)
+	words := make([]string, 0)
+	var start int
+	for i := 1; i < len(runes); i++ {
+		// words start at an upper case letter following a lower case one,
+		// or at the last upper case letter of an initialism followed by a lower case one
+		if unicode.IsUpper(runes[i]) &&
+			(!unicode.IsUpper(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+
+	return append(words, string(runes[start:]))
+}
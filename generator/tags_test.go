@@ -0,0 +1,137 @@
+package generator
+
+import (
+	"go/ast"
+	"testing"
+)
+
+// returns the js property name of an untagged field following strategy
+func propertyNameOf(name string, strategy FieldNameStrategy) string {
+	gen := &generator{config: &Config{FieldNameStrategy: strategy}}
+	return gen.propertyName(&fieldTag{}, &ast.Ident{Name: name})
+}
+
+func TestPropertyNames(t *testing.T) {
+	tests := []struct {
+		name  string
+		camel string
+		snake string
+	}{
+		{"Name", "name", "name"},
+		{"UserID", "userID", "user_id"},
+		{"HTTPStatus", "httpStatus", "http_status"},
+		{"ID", "id", "id"},
+		{"APIKey", "apiKey", "api_key"},
+		{"ParseURL", "parseURL", "parse_url"},
+		{"XMLHTTPRequest", "xmlhttpRequest", "xmlhttp_request"},
+		{"Status2", "status2", "status2"},
+	}
+	for _, test := range tests {
+		if got := propertyNameOf(test.name, AsIs); got != test.name {
+			t.Errorf("Expected %s as is, got %s", test.name, got)
+		}
+		if got := propertyNameOf(test.name, CamelCase); got != test.camel {
+			t.Errorf("Expected %s in camelCase to be %s, got %s", test.name, test.camel, got)
+		}
+		if got := propertyNameOf(test.name, SnakeCase); got != test.snake {
+			t.Errorf("Expected %s in snake_case to be %s, got %s", test.name, test.snake, got)
+		}
+	}
+}
+
+func TestTagsWinOverFieldNameStrategy(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+type User struct {
+	UserID    int
+	HTTPCode  int    ` + "`js:\"Status\"`" + `
+	FirstName string ` + "`wasm:\"given\"`" + `
+}
+
+func Greet(u User) string {
+	return fmt.Sprint(u.UserID, " ", u.HTTPCode, " ", u.FirstName)
+}
+`
+	for _, strategy := range []FieldNameStrategy{CamelCase, SnakeCase} {
+		config := NewConfig()
+		config.FieldNameStrategy = strategy
+		script := `Greet({` + propertyNameOf("UserID", strategy) + `: 7, Status: 200, given: "ann"})`
+		if got := runWasm(t, src, config, script); got != "7 200 ann" {
+			t.Errorf("Expected 7 200 ann, got %s", got)
+		}
+	}
+}
+
+func TestKeyAliasing(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+type User struct {
+	UserID    int
+	FirstName string
+	LastName  string ` + "`js:\"surname\"`" + `
+}
+
+func Greet(u User) string {
+	return fmt.Sprintf("%d %q %q", u.UserID, u.FirstName, u.LastName)
+}
+`
+	config := NewConfig()
+	config.KeyAliasing = true
+	// tagged fields aren't aliased, so last_name is ignored
+	script := `[Greet({user_id: 7, firstName: "ann", surname: "lee"}), Greet({UserID: 8, first_name: "bo", last_name: "kim"})].join(",")`
+	got := runWasm(t, src, config, script)
+	if want := `7 "ann" "lee",8 "bo" "<undefined>"`; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestFieldTransforms(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+type Handle string
+
+type Signup struct {
+	Email  string ` + "`js:\"email,transform=trim|lower\"`" + `
+	Handle Handle ` + "`js:\"handle,transform=upper\"`" + `
+	Code   string ` + "`js:\"code,transform=reverse\"`" + `
+}
+
+func Register(s Signup) string {
+	return fmt.Sprintf("%q %q %q", s.Email, s.Handle, s.Code)
+}
+
+func Lookup(h Handle) string {
+	return string(h)
+}
+
+func reverse(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
+`
+	config := NewConfig()
+	// codes are passed reversed:
+	// 	name.Code = reverse(name.Code)
+	config.RegisterTransform("reverse", func(value ast.Expr) ast.Expr {
+		return &ast.CallExpr{Fun: &ast.Ident{Name: "reverse"}, Args: []ast.Expr{value}}
+	})
+	got := runWasm(t, src, config, `Register({email: "  Ann@Example.COM ", handle: "ann", code: "abc"}) + " " + Lookup("bo")`)
+	if want := `"ann@example.com" "ANN" "cba" bo`; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	for _, field := range []string{"Count int `js:\"count,transform=trim\"`", "Name string `js:\"name,transform=shout\"`"} {
+		if _, err := tryGenerate("package main\n\ntype T struct {\n\t"+field+"\n}\n\nfunc F(t T) {}\n", NewConfig()); err == nil {
+			t.Errorf("Expected an error generating %s", field)
+		}
+	}
+}
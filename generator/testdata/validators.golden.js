@@ -0,0 +1,32 @@
+(() => {
+	const wrap = (name, validate) => {
+		const fn = globalThis[name];
+		globalThis[name] = function (...args) {
+			validate(args);
+			return fn.apply(this, args);
+		};
+	};
+
+	const check = (ok, name, param, type) => {
+		if (!ok) {
+			throw new TypeError(`${name}: argument "${param}" must be ${type}`);
+		}
+	};
+
+	const isThenable = (value) => typeof value?.then === "function";
+
+	wrap("Flag", (args) => {
+		check(typeof args[0] === "boolean", "Flag", "on", "bool");
+		check(typeof args[1] === "object" && args[1] !== null, "Flag", "_", "Point");
+	});
+
+	wrap("Flags", (args) => {
+		check(typeof args[0] === "number", "Flags", "flags", "[]bool");
+	});
+
+	wrap("Scale", (args) => {
+		check(args[0] === undefined || args[0] === null || (typeof args[0] === "object" && args[0] !== null), "Scale", "p", "*Point");
+		check(typeof args[1] === "number", "Scale", "factor", "float64");
+		check((Array.isArray(args[2]) || ArrayBuffer.isView(args[2])) && Array.prototype.every.call(args[2], (e0) => typeof e0 === "string"), "Scale", "labels", "[]string");
+	});
+})();
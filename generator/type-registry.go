@@ -0,0 +1,834 @@
+package generator
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"sort"
+	"strconv"
+)
+
+// a typeResolver returns an expression holding jsValue converted to a registered type
+// along with any statements needed to compute it.
+// temporaries declared by the resolver should be named after name
+type typeResolver func(gen *generator, name *ast.Ident, jsValue ast.Expr, nativeType ast.Expr) (ast.Expr, []ast.Stmt, error)
+
+// A TypeResolver returns an expression holding jsValue converted to a registered type
+// along with any statements needed to compute it.
+// Temporaries declared by the resolver should be named after name
+type TypeResolver func(name *ast.Ident, jsValue ast.Expr) (ast.Expr, []ast.Stmt, error)
+
+// RegisterType makes the generator resolve the given type (e.g. "decimal.Decimal") with resolver
+// instead of deriving a resolver from its declaration.
+// Packages referenced by the type are imported into the wrapper file automatically,
+// any other import paths used by the resolved code must be listed in imports
+func (config *Config) RegisterType(typeName string, resolver TypeResolver, imports ...string) {
+	if config.types == nil {
+		config.types = make(map[string]*registeredType)
+	}
+
+	config.types[typeName] = &registeredType{
+		imports: imports,
+		resolver: func(gen *generator, name *ast.Ident, jsValue ast.Expr, nativeType ast.Expr) (ast.Expr, []ast.Stmt, error) {
+			err := gen.addTypeImports(nativeType)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			return resolver(name, jsValue)
+		},
+	}
+}
+
+// RegisterFieldResolver makes the generator resolve a single field of a struct type declared in the package,
+// given by its path (e.g. "Order.Total"), with resolver instead of the resolver of the field's type.
+// Any import paths used by the resolved code must be listed in imports
+func (config *Config) RegisterFieldResolver(fieldPath string, resolver TypeResolver, imports ...string) {
+	if config.fields == nil {
+		config.fields = make(map[string]*registeredType)
+	}
+
+	config.fields[fieldPath] = &registeredType{
+		imports: imports,
+		resolver: func(gen *generator, name *ast.Ident, jsValue ast.Expr, nativeType ast.Expr) (ast.Expr, []ast.Stmt, error) {
+			return resolver(name, jsValue)
+		},
+	}
+}
+
+// A Transform returns an expression holding the string value transformed (e.g. strings.ToLower(value))
+type Transform func(value ast.Expr) ast.Expr
+
+// RegisterTransform makes the generator apply transform to the string fields naming it in their tag's
+// transform option (e.g. js:"email,transform=trim|lower"), in the listed order, once they're resolved.
+// Any import paths used by the transformed code must be listed in imports
+func (config *Config) RegisterTransform(name string, transform Transform, imports ...string) {
+	if config.transforms == nil {
+		config.transforms = make(map[string]*registeredTransform)
+	}
+
+	config.transforms[name] = &registeredTransform{
+		imports:   imports,
+		transform: transform,
+	}
+}
+
+// RegisterPrototypes makes the generator resolve the given interface type (e.g. "Shape")
+// into the go type mapped from the js value's class name (e.g. {"Circle": "*Circle"}),
+// read from its constructor's name. Values of other classes throw
+func (config *Config) RegisterPrototypes(interfaceName string, classes map[string]string) {
+	if config.types == nil {
+		config.types = make(map[string]*registeredType)
+	}
+
+	config.types[interfaceName] = &registeredType{
+		resolver: resolvePrototype(classes),
+	}
+}
+
+// RegisterEnumObject makes the generator resolve the given named basic type (e.g. "Status")
+// from either its value or a js object holding its value in valueField
+// (e.g. "value" for {value: 1, label: "Active"})
+func (config *Config) RegisterEnumObject(typeName string, valueField string) {
+	if config.types == nil {
+		config.types = make(map[string]*registeredType)
+	}
+
+	config.types[typeName] = &registeredType{
+		resolver: resolveEnumObject(valueField),
+	}
+}
+
+type registeredType struct {
+	// import paths required by the resolved code
+	imports  []string
+	resolver typeResolver
+}
+
+type registeredTransform struct {
+	// import paths required by the transformed code
+	imports   []string
+	transform Transform
+}
+
+// transforms available to every string field, custom ones being added with Config.RegisterTransform
+var builtinTransforms = map[string]*registeredTransform{
+	"lower": {
+		imports:   []string{"strings"},
+		transform: stringsCall("ToLower"),
+	},
+	"upper": {
+		imports:   []string{"strings"},
+		transform: stringsCall("ToUpper"),
+	},
+	"trim": {
+		imports:   []string{"strings"},
+		transform: stringsCall("TrimSpace"),
+	},
+}
+
+// returns a transform calling the named func of the strings package on the value
+func stringsCall(funcName string) Transform {
+	return func(value ast.Expr) ast.Expr {
+		return &ast.CallExpr{
+			Fun: &ast.SelectorExpr{
+				X:   &ast.Ident{Name: "strings"},
+				Sel: &ast.Ident{Name: funcName},
+			},
+			Args: []ast.Expr{value},
+		}
+	}
+}
+
+// resolvers for types that can't be derived from their declaration,
+// keyed by the source representation of the type (e.g. "*bytes.Buffer")
+var builtinTypes = map[string]*registeredType{
+	"*bytes.Buffer": {
+		imports:  []string{"bytes"},
+		resolver: resolveBytesBuffer,
+	},
+	"json.RawMessage": {
+		imports:  []string{"encoding/json"},
+		resolver: resolveRawMessage,
+	},
+	"time.Month": {
+		imports:  []string{"time"},
+		resolver: resolveTimeEnum("wasmResolveMonth"),
+	},
+	"time.Weekday": {
+		imports:  []string{"time"},
+		resolver: resolveTimeEnum("wasmResolveWeekday"),
+	},
+	"time.Duration": {
+		imports:  []string{"time"},
+		resolver: resolveTimeEnum("wasmResolveDuration"),
+	},
+	"time.Time": {
+		imports:  []string{"time"},
+		resolver: resolveTime,
+	},
+	"*big.Rat": {
+		imports:  []string{"math/big"},
+		resolver: resolveBigRat,
+	},
+	"*net.IPNet": {
+		imports:  []string{"net"},
+		resolver: resolveIPNet,
+	},
+	"context.CancelFunc": {
+		imports:  []string{"context"},
+		resolver: resolveCancelFunc,
+	},
+	"chan struct{}": {
+		resolver: resolveAbortChan,
+	},
+	"<-chan struct{}": {
+		resolver: resolveAbortChan,
+	},
+}
+
+// resolves a *bytes.Buffer from either a js string or a Uint8Array,
+// other array-likes (e.g. ArrayBuffers or arrays of numbers) are copied into a Uint8Array first
+//
+// generated resolver:
+//
+//	buf := new(bytes.Buffer)
+//	if jsValue.Type() == js.TypeString {
+//		buf.WriteString(jsValue.String())
+//	} else {
+//		bufView := jsValue
+//		if !bufView.InstanceOf(js.Global().Get("Uint8Array")) {
+//			bufView = js.Global().Get("Uint8Array").New(bufView)
+//		}
+//		bufBytes := make([]byte, bufView.Length())
+//		js.CopyBytesToGo(bufBytes, bufView)
+//		buf.Write(bufBytes)
+//	}
+func resolveBytesBuffer(gen *generator, name *ast.Ident, jsValue ast.Expr, nativeType ast.Expr) (ast.Expr, []ast.Stmt, error) {
+	bytesIdent := &ast.Ident{Name: name.Name + "Bytes"}
+	viewIdent := &ast.Ident{Name: name.Name + "View"}
+	uint8Array := &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X: &ast.CallExpr{
+				Fun: &ast.SelectorExpr{
+					X:   gen.jsIdent(),
+					Sel: &ast.Ident{Name: "Global"},
+				},
+			},
+			Sel: &ast.Ident{Name: "Get"},
+		},
+		Args: []ast.Expr{&ast.BasicLit{Kind: token.STRING, Value: `"Uint8Array"`}},
+	}
+
+	return name, []ast.Stmt{
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{name},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun: &ast.Ident{Name: "new"},
+					Args: []ast.Expr{
+						&ast.SelectorExpr{
+							X:   &ast.Ident{Name: "bytes"},
+							Sel: &ast.Ident{Name: "Buffer"},
+						},
+					},
+				},
+			},
+		},
+		&ast.IfStmt{
+			Cond: &ast.BinaryExpr{
+				X: &ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X:   jsValue,
+						Sel: &ast.Ident{Name: "Type"},
+					},
+				},
+				Op: token.EQL,
+				Y: &ast.SelectorExpr{
+					X:   gen.jsIdent(),
+					Sel: &ast.Ident{Name: "TypeString"},
+				},
+			},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					&ast.ExprStmt{
+						X: &ast.CallExpr{
+							Fun: &ast.SelectorExpr{
+								X:   name,
+								Sel: &ast.Ident{Name: "WriteString"},
+							},
+							Args: []ast.Expr{
+								&ast.CallExpr{
+									Fun: &ast.SelectorExpr{
+										X:   jsValue,
+										Sel: &ast.Ident{Name: "String"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			Else: &ast.BlockStmt{
+				List: []ast.Stmt{
+					&ast.AssignStmt{
+						Lhs: []ast.Expr{viewIdent},
+						Tok: token.DEFINE,
+						Rhs: []ast.Expr{jsValue},
+					},
+					&ast.IfStmt{
+						Cond: &ast.UnaryExpr{
+							Op: token.NOT,
+							X: &ast.CallExpr{
+								Fun: &ast.SelectorExpr{
+									X:   viewIdent,
+									Sel: &ast.Ident{Name: "InstanceOf"},
+								},
+								Args: []ast.Expr{uint8Array},
+							},
+						},
+						Body: &ast.BlockStmt{
+							List: []ast.Stmt{
+								&ast.AssignStmt{
+									Lhs: []ast.Expr{viewIdent},
+									Tok: token.ASSIGN,
+									Rhs: []ast.Expr{
+										&ast.CallExpr{
+											Fun: &ast.SelectorExpr{
+												X:   uint8Array,
+												Sel: &ast.Ident{Name: "New"},
+											},
+											Args: []ast.Expr{viewIdent},
+										},
+									},
+								},
+							},
+						},
+					},
+					&ast.AssignStmt{
+						Lhs: []ast.Expr{bytesIdent},
+						Tok: token.DEFINE,
+						Rhs: []ast.Expr{
+							&ast.CallExpr{
+								Fun: &ast.Ident{Name: "make"},
+								Args: []ast.Expr{
+									&ast.ArrayType{Elt: &ast.Ident{Name: "byte"}},
+									&ast.CallExpr{
+										Fun: &ast.SelectorExpr{
+											X:   viewIdent,
+											Sel: &ast.Ident{Name: "Length"},
+										},
+									},
+								},
+							},
+						},
+					},
+					&ast.ExprStmt{
+						X: &ast.CallExpr{
+							Fun: &ast.SelectorExpr{
+								X:   gen.jsIdent(),
+								Sel: &ast.Ident{Name: "CopyBytesToGo"},
+							},
+							Args: []ast.Expr{bytesIdent, viewIdent},
+						},
+					},
+					&ast.ExprStmt{
+						X: &ast.CallExpr{
+							Fun: &ast.SelectorExpr{
+								X:   name,
+								Sel: &ast.Ident{Name: "Write"},
+							},
+							Args: []ast.Expr{bytesIdent},
+						},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+// resolves a json.RawMessage holding the JSON encoding of any js value,
+// values JSON can't encode (e.g. undefined) are held as null
+//
+// generated resolver:
+//
+//	json.RawMessage(wasmStringifyJSON(jsValue))
+func resolveRawMessage(gen *generator, name *ast.Ident, jsValue ast.Expr, nativeType ast.Expr) (ast.Expr, []ast.Stmt, error) {
+	gen.useHelper("json")
+	return &ast.CallExpr{
+		Fun: nativeType,
+		Args: []ast.Expr{
+			&ast.CallExpr{
+				Fun:  &ast.Ident{Name: "wasmStringifyJSON"},
+				Args: []ast.Expr{jsValue},
+			},
+		},
+	}, nil, nil
+}
+
+// resolves a *big.Rat from a fraction ("3/4") or decimal ("0.75") string,
+// throwing when the string isn't a valid rational
+//
+// generated resolver:
+//
+//	rat, ratOk := new(big.Rat).SetString(jsValue.String())
+//	if !ratOk {
+//		panic(js.Global().Get("Error").New("Invalid rational " + jsValue.String()))
+//	}
+func resolveBigRat(gen *generator, name *ast.Ident, jsValue ast.Expr, nativeType ast.Expr) (ast.Expr, []ast.Stmt, error) {
+	okIdent := &ast.Ident{Name: name.Name + "Ok"}
+	jsString := &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   jsValue,
+			Sel: &ast.Ident{Name: "String"},
+		},
+	}
+
+	return name, []ast.Stmt{
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{name, okIdent},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X: &ast.CallExpr{
+							Fun: &ast.Ident{Name: "new"},
+							Args: []ast.Expr{
+								&ast.SelectorExpr{
+									X:   &ast.Ident{Name: "big"},
+									Sel: &ast.Ident{Name: "Rat"},
+								},
+							},
+						},
+						Sel: &ast.Ident{Name: "SetString"},
+					},
+					Args: []ast.Expr{jsString},
+				},
+			},
+		},
+		&ast.IfStmt{
+			Cond: &ast.UnaryExpr{Op: token.NOT, X: okIdent},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					gen.throwStmt(&ast.BinaryExpr{
+						X:  &ast.BasicLit{Kind: token.STRING, Value: `"Invalid rational "`},
+						Op: token.ADD,
+						Y:  jsString,
+					}),
+				},
+			},
+		},
+	}, nil
+}
+
+// resolves a *net.IPNet from a CIDR string (e.g. "10.0.0.0/8"),
+// throwing when the string can't be parsed
+//
+// generated resolver:
+//
+//	_, network, networkErr := net.ParseCIDR(jsValue.String())
+//	if networkErr != nil {
+//		panic(js.Global().Get("Error").New(networkErr.Error()))
+//	}
+func resolveIPNet(gen *generator, name *ast.Ident, jsValue ast.Expr, nativeType ast.Expr) (ast.Expr, []ast.Stmt, error) {
+	errIdent := &ast.Ident{Name: name.Name + "Err"}
+
+	return name, []ast.Stmt{
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{&ast.Ident{Name: "_"}, name, errIdent},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X:   &ast.Ident{Name: "net"},
+						Sel: &ast.Ident{Name: "ParseCIDR"},
+					},
+					Args: []ast.Expr{
+						&ast.CallExpr{
+							Fun: &ast.SelectorExpr{
+								X:   jsValue,
+								Sel: &ast.Ident{Name: "String"},
+							},
+						},
+					},
+				},
+			},
+		},
+		&ast.IfStmt{
+			Cond: &ast.BinaryExpr{
+				X:  errIdent,
+				Op: token.NEQ,
+				Y:  &ast.Ident{Name: "nil"},
+			},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					gen.throwStmt(&ast.CallExpr{
+						Fun: &ast.SelectorExpr{
+							X:   errIdent,
+							Sel: &ast.Ident{Name: "Error"},
+						},
+					}),
+				},
+			},
+		},
+	}, nil
+}
+
+// resolves a done channel closed when the js AbortSignal aborts,
+// absent signals give a channel that is never closed
+//
+// generated resolver:
+//
+//	wasmAbortChan(jsValue)
+func resolveAbortChan(gen *generator, name *ast.Ident, jsValue ast.Expr, nativeType ast.Expr) (ast.Expr, []ast.Stmt, error) {
+	gen.useHelper("abort")
+	return &ast.CallExpr{
+		Fun:  &ast.Ident{Name: "wasmAbortChan"},
+		Args: []ast.Expr{jsValue},
+	}, nil, nil
+}
+
+// resolves a context.CancelFunc calling back into the js function,
+// absent functions give a cancel func that does nothing
+//
+// generated resolver:
+//
+//	wasmCancelFunc(jsValue)
+func resolveCancelFunc(gen *generator, name *ast.Ident, jsValue ast.Expr, nativeType ast.Expr) (ast.Expr, []ast.Stmt, error) {
+	gen.useHelper("cancel")
+	return &ast.CallExpr{
+		Fun:  &ast.Ident{Name: "wasmCancelFunc"},
+		Args: []ast.Expr{jsValue},
+	}, nil, nil
+}
+
+// returns a resolver for a time type (time.Month, time.Weekday or time.Duration)
+// accepting either its number or its name through the given helper
+//
+// generated resolver:
+//
+//	wasmResolveMonth(jsValue)
+func resolveTimeEnum(helperFunc string) typeResolver {
+	return func(gen *generator, name *ast.Ident, jsValue ast.Expr, nativeType ast.Expr) (ast.Expr, []ast.Stmt, error) {
+		gen.useHelper("time")
+		return &ast.CallExpr{
+			Fun:  &ast.Ident{Name: helperFunc},
+			Args: []ast.Expr{jsValue},
+		}, nil, nil
+	}
+}
+
+// resolves a time.Time from a js millisecond timestamp (e.g. Date.now())
+//
+// generated resolver:
+//
+//	time.UnixMilli(int64(jsValue.Int()))
+func resolveTime(gen *generator, name *ast.Ident, jsValue ast.Expr, nativeType ast.Expr) (ast.Expr, []ast.Stmt, error) {
+	return &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   &ast.Ident{Name: "time"},
+			Sel: &ast.Ident{Name: "UnixMilli"},
+		},
+		Args: []ast.Expr{
+			&ast.CallExpr{
+				Fun: &ast.Ident{Name: "int64"},
+				Args: []ast.Expr{
+					&ast.CallExpr{
+						Fun: &ast.SelectorExpr{
+							X:   jsValue,
+							Sel: &ast.Ident{Name: "Int"},
+						},
+					},
+				},
+			},
+		},
+	}, nil, nil
+}
+
+// resolves a value of a configured handle type by passing the js handle int
+// to the lookup registered for the type at runtime,
+// throwing when the lookup doesn't return a value of the type
+//
+// generated resolver:
+//
+//	file, fileOk := wasmLookupHandle("*os.File", jsValue.Int()).(*os.File)
+//	if !fileOk {
+//		panic(js.Global().Get("Error").New("Handle is not a *os.File"))
+//	}
+func resolveHandle(gen *generator, name *ast.Ident, jsValue ast.Expr, nativeType ast.Expr) (ast.Expr, []ast.Stmt, error) {
+	err := gen.addTypeImports(nativeType)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	gen.useHelper("handles")
+	ok := &ast.Ident{Name: name.Name + "Ok"}
+	return name, []ast.Stmt{
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{name, ok},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.TypeAssertExpr{
+					X: &ast.CallExpr{
+						Fun: &ast.Ident{Name: "wasmLookupHandle"},
+						Args: []ast.Expr{
+							&ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(types.ExprString(nativeType))},
+							&ast.CallExpr{
+								Fun: &ast.SelectorExpr{
+									X:   jsValue,
+									Sel: &ast.Ident{Name: "Int"},
+								},
+							},
+						},
+					},
+					Type: nativeType,
+				},
+			},
+		},
+		&ast.IfStmt{
+			Cond: &ast.UnaryExpr{Op: token.NOT, X: ok},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					gen.throwStmt(&ast.BasicLit{
+						Kind:  token.STRING,
+						Value: strconv.Quote("Handle is not a " + types.ExprString(nativeType)),
+					}),
+				},
+			},
+		},
+	}, nil
+}
+
+// returns a resolver for an interface type that dispatches on the class name of the js value,
+// resolving the go type mapped from it
+//
+// generated resolver:
+//
+//	var shape Shape
+//	switch jsValue.Get("constructor").Get("name").String() {
+//	case "Circle":
+//		...
+//		shape = (*Circle)(shapeCircle)
+//	default:
+//		panic(js.Global().Get("Error").New("Unknown class for shape"))
+//	}
+func resolvePrototype(classes map[string]string) typeResolver {
+	return func(gen *generator, name *ast.Ident, jsValue ast.Expr, nativeType ast.Expr) (ast.Expr, []ast.Stmt, error) {
+		classNames := make([]string, 0, len(classes))
+		for className := range classes {
+			classNames = append(classNames, className)
+		}
+		sort.Strings(classNames)
+
+		cases := make([]ast.Stmt, 0, len(classNames)+1)
+		for _, className := range classNames {
+			typeSrc := classes[className]
+			classType, err := parser.ParseExpr(typeSrc)
+			if err != nil {
+				return nil, nil, fmt.Errorf("Malformed type %s for class %s: %v", typeSrc, className, err)
+			}
+			clearPositions(classType)
+
+			classExpr, classResolver, err := gen.ResolveValue(
+				// named after the class, as types like *shapes.Circle don't make identifiers
+				&ast.Ident{Name: name.Name + identPart(className)},
+				jsValue,
+				classType,
+				nil,
+			)
+			if err != nil {
+				return nil, nil, fmt.Errorf("Unresolved type %s for class %s: %v", typeSrc, className, err)
+			}
+
+			// pointer types are parenthesized in conversions
+			var conversion ast.Expr = classType
+			if _, ok := classType.(*ast.StarExpr); ok {
+				conversion = &ast.ParenExpr{X: classType}
+			}
+
+			cases = append(cases, &ast.CaseClause{
+				List: []ast.Expr{&ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(className)}},
+				Body: append(classResolver, &ast.AssignStmt{
+					Lhs: []ast.Expr{name},
+					Tok: token.ASSIGN,
+					Rhs: []ast.Expr{
+						&ast.CallExpr{
+							Fun:  conversion,
+							Args: []ast.Expr{classExpr},
+						},
+					},
+				}),
+			})
+		}
+
+		cases = append(cases, &ast.CaseClause{
+			Body: []ast.Stmt{
+				gen.throwStmt(&ast.BasicLit{
+					Kind:  token.STRING,
+					Value: strconv.Quote("Unknown class for " + name.Name),
+				}),
+			},
+		})
+
+		return name, []ast.Stmt{
+			&ast.DeclStmt{
+				Decl: &ast.GenDecl{
+					Tok: token.VAR,
+					Specs: []ast.Spec{
+						&ast.ValueSpec{
+							Names: []*ast.Ident{name},
+							Type:  nativeType,
+						},
+					},
+				},
+			},
+			&ast.SwitchStmt{
+				Tag: &ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X: &ast.CallExpr{
+							Fun: &ast.SelectorExpr{
+								X: &ast.CallExpr{
+									Fun: &ast.SelectorExpr{
+										X:   jsValue,
+										Sel: &ast.Ident{Name: "Get"},
+									},
+									Args: []ast.Expr{&ast.BasicLit{Kind: token.STRING, Value: `"constructor"`}},
+								},
+								Sel: &ast.Ident{Name: "Get"},
+							},
+							Args: []ast.Expr{&ast.BasicLit{Kind: token.STRING, Value: `"name"`}},
+						},
+						Sel: &ast.Ident{Name: "String"},
+					},
+				},
+				Body: &ast.BlockStmt{List: cases},
+			},
+		}, nil
+	}
+}
+
+// returns a resolver for a named basic type that accepts objects holding the value in valueField
+//
+// generated resolver:
+//
+//	statusValue := jsValue
+//	if statusValue.Type() == js.TypeObject {
+//		statusValue = statusValue.Get("value")
+//	} else if statusValue.Type() == js.TypeString {
+//		statusValue = js.Global().Get("Number").Invoke(statusValue)
+//	}
+//	Status(statusValue.Int())
+//
+// the string branch is only generated for numeric types, whose values may be passed as property names (e.g. map keys)
+func resolveEnumObject(valueField string) typeResolver {
+	return func(gen *generator, name *ast.Ident, jsValue ast.Expr, nativeType ast.Expr) (ast.Expr, []ast.Stmt, error) {
+		basicType, ok := gen.underlyingType(nativeType).(*ast.Ident)
+		if !ok {
+			return nil, nil, fmt.Errorf("Enum object type %s must have a basic underlying type", types.ExprString(nativeType))
+		}
+
+		value := &ast.Ident{Name: name.Name + "Value"}
+		valueExpr, valueResolver, err := gen.ResolveValue(name, value, basicType, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var numeric ast.Stmt
+		if basicType.Name != "string" && basicType.Name != "bool" {
+			numeric = &ast.IfStmt{
+				Cond: &ast.BinaryExpr{
+					X: &ast.CallExpr{
+						Fun: &ast.SelectorExpr{
+							X:   value,
+							Sel: &ast.Ident{Name: "Type"},
+						},
+					},
+					Op: token.EQL,
+					Y: &ast.SelectorExpr{
+						X:   gen.jsIdent(),
+						Sel: &ast.Ident{Name: "TypeString"},
+					},
+				},
+				Body: &ast.BlockStmt{
+					List: []ast.Stmt{
+						&ast.AssignStmt{
+							Lhs: []ast.Expr{value},
+							Tok: token.ASSIGN,
+							Rhs: []ast.Expr{
+								&ast.CallExpr{
+									Fun: &ast.SelectorExpr{
+										X: &ast.CallExpr{
+											Fun: &ast.SelectorExpr{
+												X: &ast.CallExpr{
+													Fun: &ast.SelectorExpr{
+														X:   gen.jsIdent(),
+														Sel: &ast.Ident{Name: "Global"},
+													},
+												},
+												Sel: &ast.Ident{Name: "Get"},
+											},
+											Args: []ast.Expr{&ast.BasicLit{Kind: token.STRING, Value: `"Number"`}},
+										},
+										Sel: &ast.Ident{Name: "Invoke"},
+									},
+									Args: []ast.Expr{value},
+								},
+							},
+						},
+					},
+				},
+			}
+		}
+
+		resolver := []ast.Stmt{
+			&ast.AssignStmt{
+				Lhs: []ast.Expr{value},
+				Tok: token.DEFINE,
+				Rhs: []ast.Expr{jsValue},
+			},
+			&ast.IfStmt{
+				Cond: &ast.BinaryExpr{
+					X: &ast.CallExpr{
+						Fun: &ast.SelectorExpr{
+							X:   value,
+							Sel: &ast.Ident{Name: "Type"},
+						},
+					},
+					Op: token.EQL,
+					Y: &ast.SelectorExpr{
+						X:   gen.jsIdent(),
+						Sel: &ast.Ident{Name: "TypeObject"},
+					},
+				},
+				Body: &ast.BlockStmt{
+					List: []ast.Stmt{
+						&ast.AssignStmt{
+							Lhs: []ast.Expr{value},
+							Tok: token.ASSIGN,
+							Rhs: []ast.Expr{
+								&ast.CallExpr{
+									Fun: &ast.SelectorExpr{
+										X:   value,
+										Sel: &ast.Ident{Name: "Get"},
+									},
+									Args: []ast.Expr{&ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(valueField)}},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+		if numeric != nil {
+			resolver[1].(*ast.IfStmt).Else = numeric
+		}
+
+		return &ast.CallExpr{
+			Fun:  nativeType,
+			Args: []ast.Expr{valueExpr},
+		}, append(resolver, valueResolver...), nil
+	}
+}
@@ -3,7 +3,11 @@ package generator
 import (
 	"fmt"
 	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
 	"strings"
+	"unicode"
 )
 
 func (gen *generator) wrapperName(srcName string) string {
@@ -15,6 +19,12 @@ func (gen *generator) wrapperName(srcName string) string {
 	}
 }
 
+// reports whether fn is an exported top-level function that should be exposed to js,
+// generated declarations are named with a "Wasm" suffix, or a "wasm" prefix when unexported, and never exposed
+func isExposed(fn *ast.FuncDecl) bool {
+	return fn.Recv == nil && fn.Name.IsExported() && !strings.HasSuffix(fn.Name.Name, "Wasm")
+}
+
 func (gen *generator) getTypeAlias(name string) (ast.Expr, error) {
 	if expr, ok := gen.typeAliases[name]; ok {
 		return expr, nil
@@ -36,4 +46,359 @@ func (gen *generator) getTypeAlias(name string) (ast.Expr, error) {
 	}
 
 	return nil, fmt.Errorf("No type alias \"%s\" found in the current package", name)
-}
\ No newline at end of file
+}
+
+// returns the underlying type of the given type,
+// following identifiers through the current package's type declarations
+func (gen *generator) underlyingType(nativeType ast.Expr) ast.Expr {
+	for {
+		ident, ok := nativeType.(*ast.Ident)
+		if !ok {
+			return nativeType
+		}
+
+		underlying, err := gen.getTypeAlias(ident.Name)
+		if err != nil {
+			return nativeType
+		}
+
+		nativeType = underlying
+	}
+}
+
+// returns a copy of params with every parameter named,
+// unnamed and blank parameters are named after their position
+func namedParams(params *ast.FieldList) *ast.FieldList {
+	var i int
+	named := make([]*ast.Field, 0, len(params.List))
+	for _, param := range params.List {
+		names := make([]*ast.Ident, 0, len(param.Names))
+		for _, name := range param.Names {
+			if name.Name == "_" {
+				name = &ast.Ident{Name: "arg" + strconv.Itoa(i)}
+			}
+
+			names = append(names, name)
+			i++
+		}
+
+		if len(names) == 0 {
+			names = append(names, &ast.Ident{Name: "arg" + strconv.Itoa(i)})
+			i++
+		}
+
+		named = append(named, &ast.Field{Names: names, Type: param.Type})
+	}
+
+	return &ast.FieldList{List: named}
+}
+
+// returns the position of the named parameter among params, or -1 if there's none
+func paramIndex(params *ast.FieldList, name string) int {
+	var i int
+	for _, param := range params.List {
+		for _, paramName := range param.Names {
+			if paramName.Name == name {
+				return i
+			}
+
+			i++
+		}
+	}
+
+	return -1
+}
+
+// reports whether the named type declared in the current package is a struct, slice, array, map or pointer
+func (gen *generator) isComposite(typeName string) bool {
+	switch gen.underlyingType(&ast.Ident{Name: typeName}).(type) {
+	case *ast.StructType, *ast.ArrayType, *ast.MapType, *ast.StarExpr:
+		return true
+	default:
+		return false
+	}
+}
+
+// returns an expression evaluating to the defaults of the named type
+// declared in the current package as either
+// 	var defaultName = Name{...}
+// or
+// 	func defaultName() Name { ...
+// nil is returned if the type has no defaults
+func (gen *generator) getDefaultValue(typeName string) ast.Expr {
+	defaultName := "default" + typeName
+	for _, file := range gen.pkg.Files {
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if decl.Recv == nil && decl.Name.Name == defaultName {
+					return &ast.CallExpr{Fun: &ast.Ident{Name: defaultName}}
+				}
+			case *ast.GenDecl:
+				if decl.Tok != token.VAR {
+					continue
+				}
+
+				for _, spec := range decl.Specs {
+					for _, name := range spec.(*ast.ValueSpec).Names {
+						if name.Name == defaultName {
+							return &ast.Ident{Name: defaultName}
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// reports whether the named type declared in the current package has a
+// 	func (T) Validate() error
+// method, with either a value or pointer receiver
+func (gen *generator) hasValidateMethod(typeName string) bool {
+	for _, file := range gen.pkg.Files {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil || fn.Name.Name != "Validate" || fn.Type.Params.NumFields() != 0 {
+				continue
+			}
+
+			if fn.Type.Results.NumFields() != 1 || types.ExprString(fn.Type.Results.List[0].Type) != "error" {
+				continue
+			}
+
+			recvType := fn.Recv.List[0].Type
+			if star, ok := recvType.(*ast.StarExpr); ok {
+				recvType = star.X
+			}
+
+			if ident, ok := recvType.(*ast.Ident); ok && ident.Name == typeName {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// returns the registered resolver for the given type, or nil if it has none
+func (gen *generator) getRegisteredType(nativeType ast.Expr) *registeredType {
+	typeStr := types.ExprString(nativeType)
+	if regType, ok := gen.config.types[typeStr]; ok {
+		return regType
+	}
+
+	if regType, ok := builtinTypes[typeStr]; ok {
+		return regType
+	}
+
+	for _, handleType := range gen.config.HandleTypes {
+		if handleType == typeStr {
+			return &registeredType{resolver: resolveHandle}
+		}
+	}
+
+	return nil
+}
+
+// returns the import path of the package imported as pkgName by the source package
+func (gen *generator) importPath(pkgName string) (string, error) {
+	for _, file := range gen.pkg.Files {
+		for _, spec := range file.Imports {
+			path, err := strconv.Unquote(spec.Path.Value)
+			if err != nil {
+				return "", err
+			}
+
+			name := path[strings.LastIndex(path, "/")+1:]
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+
+			if name == pkgName {
+				return path, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("No import found for package \"%s\"", pkgName)
+}
+
+// adds the imports of every package referenced by the given type to the wrapper file
+func (gen *generator) addTypeImports(nativeType ast.Expr) (err error) {
+	ast.Inspect(nativeType, func(node ast.Node) bool {
+		if sel, ok := node.(*ast.SelectorExpr); ok && err == nil {
+			if pkgIdent, ok := sel.X.(*ast.Ident); ok {
+				var path string
+				path, err = gen.importPath(pkgIdent.Name)
+				gen.imports[path] = true
+			}
+		}
+
+		return err == nil
+	})
+
+	return err
+}
+
+// reports whether the given element type is byte or uint8
+func isByte(elt ast.Expr) bool {
+	ident, ok := elt.(*ast.Ident)
+	return ok && (ident.Name == "byte" || ident.Name == "uint8")
+}
+
+// returns the import path of the package providing js.Value and friends
+func (gen *generator) jsPackage() string {
+	if gen.config.JSPackage == "" {
+		return "syscall/js"
+	}
+
+	return gen.config.JSPackage
+}
+
+// returns the identifier the js package is referenced by in generated code
+func (gen *generator) jsIdent() *ast.Ident {
+	if gen.config.JSPackageIdent != "" {
+		return &ast.Ident{Name: gen.config.JSPackageIdent}
+	}
+
+	path := gen.jsPackage()
+	return &ast.Ident{Name: path[strings.LastIndex(path, "/")+1:]}
+}
+
+// returns the signature shared by every function exposed to js:
+// 	func(this js.Value, args []js.Value) any
+func (gen *generator) wrapperFuncType() *ast.FuncType {
+	return &ast.FuncType{
+		Params: &ast.FieldList{
+			List: []*ast.Field{
+				{
+					Type: &ast.SelectorExpr{
+						X:   gen.jsIdent(),
+						Sel: &ast.Ident{Name: "Value"},
+					},
+					Names: []*ast.Ident{
+						{Name: "this"},
+					},
+				},
+				{
+					Type: &ast.ArrayType{
+						Elt: &ast.SelectorExpr{
+							X:   gen.jsIdent(),
+							Sel: &ast.Ident{Name: "Value"},
+						},
+					},
+					Names: []*ast.Ident{
+						{Name: "args"},
+					},
+				},
+			},
+		},
+		Results: &ast.FieldList{
+			List: []*ast.Field{
+				{Type: &ast.Ident{Name: "any"}},
+			},
+		},
+	}
+}
+
+// returns a statement that throws a js Error with the given message:
+// 	panic(js.Global().Get("Error").New(msg))
+func (gen *generator) throwStmt(msg ast.Expr) ast.Stmt {
+	return &ast.ExprStmt{
+		X: &ast.CallExpr{
+			Fun: &ast.Ident{Name: "panic"},
+			Args: []ast.Expr{
+				&ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X: &ast.CallExpr{
+							Fun: &ast.SelectorExpr{
+								X: &ast.CallExpr{
+									Fun: &ast.SelectorExpr{
+										X:   gen.jsIdent(),
+										Sel: &ast.Ident{Name: "Global"},
+									},
+								},
+								Sel: &ast.Ident{Name: "Get"},
+							},
+							Args: []ast.Expr{
+								&ast.BasicLit{Kind: token.STRING, Value: "\"Error\""},
+							},
+						},
+						Sel: &ast.Ident{Name: "New"},
+					},
+					Args: []ast.Expr{msg},
+				},
+			},
+		},
+	}
+}
+
+// returns an expression that is true when jsValue is neither undefined nor null:
+// 	!jsValue.IsUndefined() && !jsValue.IsNull()
+func isPresentExpr(jsValue ast.Expr) ast.Expr {
+	return &ast.BinaryExpr{
+		X: &ast.UnaryExpr{
+			Op: token.NOT,
+			X: &ast.CallExpr{
+				Fun: &ast.SelectorExpr{
+					X:   jsValue,
+					Sel: &ast.Ident{Name: "IsUndefined"},
+				},
+			},
+		},
+		Op: token.LAND,
+		Y: &ast.UnaryExpr{
+			Op: token.NOT,
+			X: &ast.CallExpr{
+				Fun: &ast.SelectorExpr{
+					X:   jsValue,
+					Sel: &ast.Ident{Name: "IsNull"},
+				},
+			},
+		},
+	}
+}
+
+// returns name with the runes that can't be part of a go identifier removed,
+// e.g. the $ of js class names like $Circle
+func identPart(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+
+		return -1
+	}, name)
+}
+
+// returns a func() any literal running body
+func bodyFuncLit(body []ast.Stmt) *ast.FuncLit {
+	return &ast.FuncLit{
+		Type: &ast.FuncType{
+			Params: &ast.FieldList{},
+			Results: &ast.FieldList{
+				List: []*ast.Field{
+					{Type: &ast.Ident{Name: "any"}},
+				},
+			},
+		},
+		Body: &ast.BlockStmt{List: body},
+	}
+}
+
+// returns jsValue, or with MergePrototype an object holding its own enumerable properties
+// over those of its prototypes:
+// 	wasmMergePrototype(jsValue)
+func (gen *generator) mergedPrototype(jsValue ast.Expr) ast.Expr {
+	if !gen.config.MergePrototype {
+		return jsValue
+	}
+
+	gen.useHelper("prototype")
+	return &ast.CallExpr{
+		Fun:  &ast.Ident{Name: "wasmMergePrototype"},
+		Args: []ast.Expr{jsValue},
+	}
+}
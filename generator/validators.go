@@ -0,0 +1,198 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"sort"
+	"strconv"
+)
+
+// returns a js script, to be run after mainWasm, that replaces each of the functions it exposes
+// with one throwing a TypeError when an argument has the wrong js type, saving a call into wasm.
+// checks are derived from the go parameter types,
+// arguments of registered types or types without a js counterpart are passed through unchecked
+func GenerateValidatorFile(pkg *ast.Package, config *Config) ([]byte, error) {
+	if pkg == nil {
+		return nil, fmt.Errorf("Pkg can't be nil")
+	}
+
+	gen := newGenerator(pkg, config)
+	funcs := make([]*ast.FuncDecl, 0)
+	for _, file := range pkg.Files {
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && isExposed(fn) {
+				funcs = append(funcs, fn)
+			}
+		}
+	}
+	sort.Slice(funcs, func(i, j int) bool {
+		return funcs[i].Name.Name < funcs[j].Name.Name
+	})
+
+	var buf bytes.Buffer
+	buf.WriteString(`(() => {
+	const wrap = (name, validate) => {
+		const fn = globalThis[name];
+		globalThis[name] = function (...args) {
+			validate(args);
+			return fn.apply(this, args);
+		};
+	};
+
+	const check = (ok, name, param, type) => {
+		if (!ok) {
+			throw new TypeError(` + "`${name}: argument \"${param}\" must be ${type}`" + `);
+		}
+	};
+
+	const isThenable = (value) => typeof value?.then === "function";
+`)
+
+	for _, fn := range funcs {
+		gen.directives = parseDirectives(fn.Doc)
+		fmt.Fprintf(&buf, "\n\twrap(%s, (args) => {\n", strconv.Quote(fn.Name.Name))
+
+		var i int
+	params:
+		for _, param := range fn.Type.Params.List {
+			names := param.Names
+			if len(names) == 0 {
+				names = []*ast.Ident{{Name: "arg" + strconv.Itoa(i)}}
+			}
+
+			for _, name := range names {
+				jsArg := "args[" + strconv.Itoa(i) + "]"
+				i++
+
+				var cond string
+				if gen.getParamDirective("options", name.Name) != nil {
+					// the remaining arguments are optional
+					break params
+				} else if gen.getParamDirective("bitmask", name.Name) != nil {
+					cond = `typeof ` + jsArg + ` === "number"`
+				} else if gen.getParamDirective("indexmap", name.Name) != nil {
+					cond = `typeof ` + jsArg + ` === "object" && ` + jsArg + ` !== null`
+				} else if gen.getParamDirective("reshape", name.Name) != nil {
+					// matrices are passed flat
+					if rowType, ok := gen.underlyingType(param.Type).(*ast.ArrayType); ok {
+						if colType, ok := rowType.Elt.(*ast.ArrayType); ok {
+							cond = gen.jsTypeCheck(jsArg, &ast.ArrayType{Elt: colType.Elt}, 0)
+						}
+					}
+				} else if variadic, ok := param.Type.(*ast.Ellipsis); ok {
+					// every remaining argument is an element
+					if eltCheck := gen.jsTypeCheck("e0", variadic.Elt, 1); eltCheck != "" {
+						cond = `args.slice(` + strconv.Itoa(i-1) + `).every((e0) => ` + eltCheck + `)`
+					}
+				} else if gen.getParamDirective("hex", name.Name) != nil {
+					cond = `typeof ` + jsArg + ` === "string"`
+				} else if gen.getParamDirective("split", name.Name) != nil {
+					// delimited strings are accepted as well as arrays
+					cond = `typeof ` + jsArg + ` === "string" || Array.isArray(` + jsArg + `)`
+				} else if gen.getParamDirective("columnar", name.Name) != nil {
+					// columns are passed as an object of arrays
+					cond = `typeof ` + jsArg + ` === "object" && ` + jsArg + ` !== null`
+				} else if gen.getParamDirective("nullAsNaN", name.Name) != nil {
+					cond = jsArg + ` === null || ` + gen.jsTypeCheck(jsArg, param.Type, 0)
+				} else {
+					cond = gen.jsTypeCheck(jsArg, param.Type, 0)
+				}
+
+				if cond == "" {
+					continue
+				}
+
+				if gen.config.Async {
+					cond = "isThenable(" + jsArg + ") || " + cond
+				}
+
+				fmt.Fprintf(
+					&buf,
+					"\t\tcheck(%s, %s, %s, %s);\n",
+					cond,
+					strconv.Quote(fn.Name.Name),
+					strconv.Quote(name.Name),
+					strconv.Quote(types.ExprString(param.Type)),
+				)
+			}
+		}
+
+		buf.WriteString("\t});\n")
+	}
+
+	buf.WriteString("})();\n")
+	return buf.Bytes(), nil
+}
+
+// returns a js condition checking that the js value is resolvable into the given type,
+// or an empty string if it can't be checked
+func (gen *generator) jsTypeCheck(jsValue string, nativeType ast.Expr, depth int) string {
+	// element checks stop at a fixed depth so recursive types terminate
+	if depth > 3 || gen.getRegisteredType(nativeType) != nil {
+		return ""
+	}
+
+	switch nativeType := nativeType.(type) {
+	case *ast.Ident:
+		switch nativeType.Name {
+		case "bool":
+			return `typeof ` + jsValue + ` === "boolean"`
+		case "string":
+			if gen.config.StringifyValues {
+				return ""
+			}
+
+			return `typeof ` + jsValue + ` === "string"`
+		case "int64", "uint64":
+			if gen.config.BigIntFor64 {
+				return `typeof ` + jsValue + ` === "number" || typeof ` + jsValue + ` === "bigint"`
+			}
+
+			return `typeof ` + jsValue + ` === "number"`
+		case "int", "int8", "int16", "int32", "rune",
+			"uint", "uint8", "byte", "uint16", "uint32", "uintptr",
+			"float32", "float64":
+			return `typeof ` + jsValue + ` === "number"`
+		case "complex64", "complex128":
+			return `typeof ` + jsValue + ` === "object" && ` + jsValue + ` !== null`
+		case "error":
+			return jsValue + ` == null || typeof ` + jsValue + ` === "string" || typeof ` + jsValue + ` === "object"`
+		}
+
+		underlying, err := gen.getTypeAlias(nativeType.Name)
+		if err != nil {
+			return ""
+		}
+
+		return gen.jsTypeCheck(jsValue, underlying, depth)
+	case *ast.StarExpr:
+		eltCheck := gen.jsTypeCheck(jsValue, nativeType.X, depth+1)
+		if eltCheck == "" {
+			return ""
+		}
+
+		return jsValue + ` === undefined || ` + jsValue + ` === null || (` + eltCheck + `)`
+	case *ast.ArrayType:
+		if elt, ok := nativeType.Elt.(*ast.Ident); ok && elt.Name == "rune" && nativeType.Len == nil {
+			return `typeof ` + jsValue + ` === "string" || Array.isArray(` + jsValue + `)`
+		}
+
+		cond := `(Array.isArray(` + jsValue + `) || ArrayBuffer.isView(` + jsValue + `))`
+		elt := "e" + strconv.Itoa(depth)
+		if eltCheck := gen.jsTypeCheck(elt, nativeType.Elt, depth+1); eltCheck != "" {
+			cond += ` && Array.prototype.every.call(` + jsValue + `, (` + elt + `) => ` + eltCheck + `)`
+		}
+
+		return cond
+	case *ast.FuncType:
+		return `typeof ` + jsValue + ` === "function"`
+	case *ast.ChanType:
+		return `typeof ` + jsValue + ` === "function" || typeof ` + jsValue + `?.next === "function"`
+	case *ast.StructType, *ast.MapType:
+		return `typeof ` + jsValue + ` === "object" && ` + jsValue + ` !== null`
+	}
+
+	return ""
+}
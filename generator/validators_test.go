@@ -0,0 +1,84 @@
+package generator
+
+import (
+	"flag"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files with the current output")
+
+func TestValidatorFile(t *testing.T) {
+	src := `package main
+
+type Point struct {
+	X, Y int
+}
+
+func Scale(p *Point, factor float64, labels []string) {}
+
+func Flag(on bool, _ Point) {}
+
+//wasm:bitmask=4 flags
+func Flags(flags []bool) {}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "lib.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := GenerateValidatorFile(&ast.Package{Name: "main", Files: map[string]*ast.File{"lib.go": file}}, nil)
+	if err != nil {
+		t.Fatalf("Error generating validators: %v", err)
+	}
+
+	golden := filepath.Join("testdata", "validators.golden.js")
+	if *update {
+		if err := os.WriteFile(golden, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("Validators don't match %s, rerun with -update if intended:\n%s", golden, got)
+	}
+
+	if _, err := exec.LookPath("node"); err != nil {
+		t.Skip("Skipping validator run without node")
+	}
+
+	// the validators wrap the exposed functions, stubbed here
+	script := `for (const name of ["Scale", "Flag", "Flags"]) {
+	globalThis[name] = () => "called";
+}
+` + string(got) + `
+const results = [];
+for (const call of [() => Scale({X: 1}, 2, ["a"]), () => Scale(null, "2", []), () => Flags(5), () => Flag(1, {})]) {
+	try {
+		results.push(call());
+	} catch (e) {
+		results.push(e.message);
+	}
+}
+console.log(results.join(","));`
+	out, err := exec.Command("node", "-e", script).CombinedOutput()
+	if err != nil {
+		t.Fatalf("Error running validators: %v\n%s", err, out)
+	}
+
+	wantOut := `called,Scale: argument "factor" must be float64,called,Flag: argument "on" must be bool`
+	if got := strings.TrimSpace(string(out)); got != wantOut {
+		t.Errorf("Expected %s, got %s", wantOut, got)
+	}
+}
@@ -0,0 +1,575 @@
+package generator
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+)
+
+// returns an expression converting goValue of the given native type into a value accepted by js.ValueOf,
+// the counterpart of ResolveValue. if the value can't be directly converted, an encoder is also returned.
+// temporaries declared by the encoder are named after name
+func (gen *generator) EncodeValue(
+	name *ast.Ident,
+	goValue ast.Expr,
+	nativeType ast.Expr,
+) (ast.Expr, []ast.Stmt, error) {
+	switch nativeType := nativeType.(type) {
+	case *ast.Ident:
+		return gen.encodeIdent(name, goValue, nativeType)
+	case *ast.StarExpr:
+		return gen.encodePointer(name, goValue, nativeType)
+	case *ast.ArrayType:
+		return gen.encodeArray(name, goValue, nativeType)
+	case *ast.StructType:
+		return gen.encodeStruct(name, goValue, nativeType)
+	case *ast.MapType:
+		return gen.encodeMap(name, goValue, nativeType)
+	case *ast.SelectorExpr:
+		return gen.encodeSelector(goValue, nativeType)
+	case *ast.InterfaceType:
+		// the dynamic value is left to js.ValueOf
+		return goValue, nil, nil
+	default:
+		return nil, nil, fmt.Errorf("Unencodable native type %s", types.ExprString(nativeType))
+	}
+}
+
+// generated encoder:
+// 	js.ValueOf(goValue)
+func (gen *generator) encodeIdent(
+	name *ast.Ident,
+	goValue ast.Expr,
+	nativeType *ast.Ident,
+) (expr ast.Expr, encoder []ast.Stmt, err error) {
+	switch typeStr := nativeType.String(); typeStr {
+	case "int64", "uint64":
+		if gen.config.BigIntFor64 {
+			// encoded as bigints so they round trip exactly:
+			// 	wasmInt64BigInt(goValue)
+			gen.useHelper("bigint")
+			return &ast.CallExpr{
+				Fun:  &ast.Ident{Name: "wasm" + strings.ToUpper(typeStr[:1]) + typeStr[1:] + "BigInt"},
+				Args: []ast.Expr{goValue},
+			}, nil, nil
+		}
+
+		return gen.jsValueOf(goValue), nil, nil
+	case "bool", "string",
+		"int", "int8", "int16", "int32", "rune",
+		"uint", "uint8", "byte", "uint16", "uint32", "uintptr",
+		"float32", "float64":
+		return gen.jsValueOf(goValue), nil, nil
+	case "complex64", "complex128":
+		// the counterpart of wasmResolveComplex:
+		// 	map[string]any{"re": real(goValue), "im": imag(goValue)}
+		re, im := `"re"`, `"im"`
+		if gen.config.RealImagComplex {
+			re, im = `"real"`, `"imag"`
+		}
+
+		return &ast.CompositeLit{
+			Type: anyMapType(),
+			Elts: []ast.Expr{
+				&ast.KeyValueExpr{
+					Key:   &ast.BasicLit{Kind: token.STRING, Value: re},
+					Value: &ast.CallExpr{Fun: &ast.Ident{Name: "real"}, Args: []ast.Expr{goValue}},
+				},
+				&ast.KeyValueExpr{
+					Key:   &ast.BasicLit{Kind: token.STRING, Value: im},
+					Value: &ast.CallExpr{Fun: &ast.Ident{Name: "imag"}, Args: []ast.Expr{goValue}},
+				},
+			},
+		}, nil, nil
+	case "any", "error":
+		// errors are passed through so async wrappers can reject with them
+		return goValue, nil, nil
+	default:
+		underlying, err := gen.getTypeAlias(typeStr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Unencodable identifier: %v", err)
+		}
+
+		// named basic types are converted to their underlying type, which js.ValueOf accepts
+		if basic, ok := underlying.(*ast.Ident); ok {
+			expr, encoder, err := gen.encodeIdent(name, &ast.CallExpr{Fun: basic, Args: []ast.Expr{goValue}}, basic)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			return expr, encoder, nil
+		}
+
+		if gen.encoding[typeStr] {
+			return nil, nil, fmt.Errorf("Unencodable recursive type %s", typeStr)
+		}
+
+		gen.encoding[typeStr] = true
+		defer delete(gen.encoding, typeStr)
+		return gen.EncodeValue(name, goValue, underlying)
+	}
+}
+
+// encodes the values of types imported from other packages that have a js counterpart.
+// durations are encoded as nanoseconds, or under a //wasm:duration=string directive
+// as their String() form (e.g. "1h30m0s")
+//
+// generated encoder:
+// 	js.ValueOf(int64(goValue))
+func (gen *generator) encodeSelector(
+	goValue ast.Expr,
+	nativeType *ast.SelectorExpr,
+) (expr ast.Expr, encoder []ast.Stmt, err error) {
+	switch typeStr := types.ExprString(nativeType); typeStr {
+	case "time.Duration":
+		if duration := gen.getDirective("duration"); duration != nil && duration.value == "string" {
+			return &ast.CallExpr{
+				Fun: &ast.SelectorExpr{X: goValue, Sel: &ast.Ident{Name: "String"}},
+			}, nil, nil
+		}
+
+		return gen.jsValueOf(&ast.CallExpr{Fun: &ast.Ident{Name: "int64"}, Args: []ast.Expr{goValue}}), nil, nil
+	case "time.Time":
+		// the counterpart of the time.Time resolver, a millisecond timestamp
+		return gen.jsValueOf(&ast.CallExpr{
+			Fun: &ast.SelectorExpr{X: goValue, Sel: &ast.Ident{Name: "UnixMilli"}},
+		}), nil, nil
+	default:
+		return nil, nil, fmt.Errorf("Unencodable type %s", typeStr)
+	}
+}
+
+// generated encoder:
+// 	var name any
+// 	if goValue != nil {
+// 		...
+// 		name = ...
+// 	}
+func (gen *generator) encodePointer(
+	name *ast.Ident,
+	goValue ast.Expr,
+	nativeType *ast.StarExpr,
+) (expr ast.Expr, encoder []ast.Stmt, err error) {
+	eltExpr, eltEncoder, err := gen.EncodeValue(
+		&ast.Ident{Name: name.Name + "Elt"},
+		&ast.ParenExpr{X: &ast.StarExpr{X: goValue}},
+		nativeType.X,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Unencodable pointer element type %v: %v", types.ExprString(nativeType.X), err)
+	}
+
+	return name, []ast.Stmt{
+		&ast.DeclStmt{
+			Decl: &ast.GenDecl{
+				Tok: token.VAR,
+				Specs: []ast.Spec{
+					&ast.ValueSpec{
+						Names: []*ast.Ident{name},
+						Type:  &ast.Ident{Name: "any"},
+					},
+				},
+			},
+		},
+		&ast.IfStmt{
+			Cond: &ast.BinaryExpr{X: goValue, Op: token.NEQ, Y: &ast.Ident{Name: "nil"}},
+			Body: &ast.BlockStmt{
+				List: append(eltEncoder, &ast.AssignStmt{
+					Lhs: []ast.Expr{name},
+					Tok: token.ASSIGN,
+					Rhs: []ast.Expr{eltExpr},
+				}),
+			},
+		},
+	}, err
+}
+
+// generated encoder:
+// 	name := make([]any, len(goValue))
+// 	for nameIdx := range goValue {
+// 		...
+// 		name[nameIdx] = ...
+// 	}
+// or with LazySlices, for slices:
+// 	name := wasmLazyArray(len(goValue), func(nameIdx int) any {
+// 		...
+// 		return ...
+// 	})
+func (gen *generator) encodeArray(
+	name *ast.Ident,
+	goValue ast.Expr,
+	nativeType *ast.ArrayType,
+) (expr ast.Expr, encoder []ast.Stmt, err error) {
+	idx := &ast.Ident{Name: name.Name + "Idx"}
+	eltExpr, eltEncoder, err := gen.EncodeValue(
+		&ast.Ident{Name: name.Name + "Elt"},
+		&ast.IndexExpr{X: goValue, Index: idx},
+		nativeType.Elt,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Unencodable array element type %v: %v", types.ExprString(nativeType.Elt), err)
+	}
+
+	if gen.config.LazySlices && nativeType.Len == nil {
+		gen.useHelper("lazyArray")
+		return name, []ast.Stmt{
+			&ast.AssignStmt{
+				Lhs: []ast.Expr{name},
+				Tok: token.DEFINE,
+				Rhs: []ast.Expr{
+					&ast.CallExpr{
+						Fun: &ast.Ident{Name: "wasmLazyArray"},
+						Args: []ast.Expr{
+							&ast.CallExpr{Fun: &ast.Ident{Name: "len"}, Args: []ast.Expr{goValue}},
+							&ast.FuncLit{
+								Type: &ast.FuncType{
+									Params: &ast.FieldList{
+										List: []*ast.Field{
+											{Names: []*ast.Ident{idx}, Type: &ast.Ident{Name: "int"}},
+										},
+									},
+									Results: &ast.FieldList{
+										List: []*ast.Field{{Type: &ast.Ident{Name: "any"}}},
+									},
+								},
+								Body: &ast.BlockStmt{
+									List: append(eltEncoder, &ast.ReturnStmt{Results: []ast.Expr{eltExpr}}),
+								},
+							},
+						},
+					},
+				},
+			},
+		}, err
+	}
+
+	return name, []ast.Stmt{
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{name},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun: &ast.Ident{Name: "make"},
+					Args: []ast.Expr{
+						&ast.ArrayType{Elt: &ast.Ident{Name: "any"}},
+						&ast.CallExpr{Fun: &ast.Ident{Name: "len"}, Args: []ast.Expr{goValue}},
+					},
+				},
+			},
+		},
+		&ast.RangeStmt{
+			Key: idx,
+			Tok: token.DEFINE,
+			X:   goValue,
+			Body: &ast.BlockStmt{
+				List: append(eltEncoder, &ast.AssignStmt{
+					Lhs: []ast.Expr{&ast.IndexExpr{X: name, Index: idx}},
+					Tok: token.ASSIGN,
+					Rhs: []ast.Expr{eltExpr},
+				}),
+			},
+		},
+	}, err
+}
+
+// fields of embedded structs are promoted into the encoded object
+//
+// generated encoder:
+// 	map[string]any{"Field": ..., ...}
+func (gen *generator) encodeStruct(
+	name *ast.Ident,
+	goValue ast.Expr,
+	nativeType *ast.StructType,
+) (expr ast.Expr, encoder []ast.Stmt, err error) {
+	// the reused object only holds the outermost struct, not structs nested in it
+	reuseObject := gen.reuseObject
+	gen.reuseObject = ""
+
+	fields := &ast.CompositeLit{Type: anyMapType()}
+	err = gen.encodeFields(name, goValue, nativeType, fields, &encoder)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if reuseObject == "" {
+		return fields, encoder, err
+	}
+
+	// every field is set on each call, so the object never holds stale values:
+	// 	name := wasmReusedObject("Example")
+	// 	name.Set("Field", ...)
+	gen.useHelper("reuse")
+	encoder = append(encoder, &ast.AssignStmt{
+		Lhs: []ast.Expr{name},
+		Tok: token.DEFINE,
+		Rhs: []ast.Expr{
+			&ast.CallExpr{
+				Fun:  &ast.Ident{Name: "wasmReusedObject"},
+				Args: []ast.Expr{&ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(reuseObject)}},
+			},
+		},
+	})
+	for _, field := range fields.Elts {
+		field := field.(*ast.KeyValueExpr)
+		encoder = append(encoder, &ast.ExprStmt{
+			X: &ast.CallExpr{
+				Fun: &ast.SelectorExpr{
+					X:   name,
+					Sel: &ast.Ident{Name: "Set"},
+				},
+				Args: []ast.Expr{field.Key, field.Value},
+			},
+		})
+	}
+
+	return name, encoder, err
+}
+
+// adds the fields of a struct to the given object literal,
+// promoting the fields of embedded structs
+func (gen *generator) encodeFields(
+	name *ast.Ident,
+	goValue ast.Expr,
+	nativeType *ast.StructType,
+	fields *ast.CompositeLit,
+	encoder *[]ast.Stmt,
+) error {
+	for _, field := range nativeType.Fields.List {
+		tag, err := parseFieldTag(field)
+		if err != nil {
+			return err
+		}
+
+		if len(field.Names) == 0 {
+			embedded, ok := gen.underlyingType(field.Type).(*ast.StructType)
+			if !ok {
+				return fmt.Errorf("Unencodable embedded field %s", types.ExprString(field.Type))
+			}
+
+			err := gen.encodeFields(name, goValue, embedded, fields, encoder)
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		for _, fieldName := range field.Names {
+			if !fieldName.IsExported() || tag.name == "-" {
+				continue
+			}
+
+			fieldExpr, fieldEncoder, err := gen.EncodeValue(
+				&ast.Ident{Name: name.Name + fieldName.Name},
+				&ast.SelectorExpr{X: goValue, Sel: &ast.Ident{Name: fieldName.Name}},
+				field.Type,
+			)
+			if err != nil {
+				return fmt.Errorf("Unencodable struct field type %v: %v", types.ExprString(field.Type), err)
+			}
+
+			*encoder = append(*encoder, fieldEncoder...)
+			fields.Elts = append(fields.Elts, &ast.KeyValueExpr{
+				Key:   &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(gen.propertyName(tag, fieldName))},
+				Value: fieldExpr,
+			})
+		}
+	}
+
+	return nil
+}
+
+// keys are encoded as the property names resolveMap reads them from
+//
+// generated encoder:
+// 	name := make(map[string]any, len(goValue))
+// 	for nameKey := range goValue {
+// 		...
+// 		name[fmt.Sprint(nameKey)] = ...
+// 	}
+func (gen *generator) encodeMap(
+	name *ast.Ident,
+	goValue ast.Expr,
+	nativeType *ast.MapType,
+) (expr ast.Expr, encoder []ast.Stmt, err error) {
+	key := &ast.Ident{Name: name.Name + "Key"}
+	eltExpr, eltEncoder, err := gen.EncodeValue(
+		&ast.Ident{Name: name.Name + "Elt"},
+		&ast.IndexExpr{X: goValue, Index: key},
+		nativeType.Value,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Unencodable map value type %v: %v", types.ExprString(nativeType.Value), err)
+	}
+
+	if gen.getDirective("jsMap") != nil {
+		return gen.encodeJSMap(name, goValue, nativeType, eltExpr, eltEncoder)
+	}
+
+	var keyExpr ast.Expr
+	var keyEncoder []ast.Stmt
+	switch underlying := gen.underlyingType(nativeType.Key).(type) {
+	case *ast.Ident:
+		switch underlying.Name {
+		case "string":
+			keyExpr = &ast.CallExpr{Fun: underlying, Args: []ast.Expr{key}}
+		case "int", "int8", "int16", "int32", "rune", "int64",
+			"uint", "uint8", "byte", "uint16", "uint32", "uint64", "uintptr",
+			"float32", "float64":
+			gen.imports["fmt"] = true
+			keyExpr = &ast.CallExpr{
+				Fun: &ast.SelectorExpr{
+					X:   &ast.Ident{Name: "fmt"},
+					Sel: &ast.Ident{Name: "Sprint"},
+				},
+				Args: []ast.Expr{key},
+			}
+		}
+	case *ast.StructType:
+		// struct keys are encoded as json:
+		// 	nameKeyJSON, _ := json.Marshal(nameKey)
+		gen.imports["encoding/json"] = true
+		keyJSON := &ast.Ident{Name: name.Name + "KeyJSON"}
+		keyEncoder = []ast.Stmt{
+			&ast.AssignStmt{
+				Lhs: []ast.Expr{keyJSON, &ast.Ident{Name: "_"}},
+				Tok: token.DEFINE,
+				Rhs: []ast.Expr{
+					&ast.CallExpr{
+						Fun: &ast.SelectorExpr{
+							X:   &ast.Ident{Name: "json"},
+							Sel: &ast.Ident{Name: "Marshal"},
+						},
+						Args: []ast.Expr{key},
+					},
+				},
+			},
+		}
+		keyExpr = &ast.CallExpr{Fun: &ast.Ident{Name: "string"}, Args: []ast.Expr{keyJSON}}
+	}
+
+	if keyExpr == nil {
+		return nil, nil, fmt.Errorf("Unencodable map key type %s", types.ExprString(nativeType.Key))
+	}
+
+	body := append(keyEncoder, eltEncoder...)
+	body = append(body, &ast.AssignStmt{
+		Lhs: []ast.Expr{&ast.IndexExpr{X: name, Index: keyExpr}},
+		Tok: token.ASSIGN,
+		Rhs: []ast.Expr{eltExpr},
+	})
+
+	return name, []ast.Stmt{
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{name},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun: &ast.Ident{Name: "make"},
+					Args: []ast.Expr{
+						anyMapType(),
+						&ast.CallExpr{Fun: &ast.Ident{Name: "len"}, Args: []ast.Expr{goValue}},
+					},
+				},
+			},
+		},
+		&ast.RangeStmt{
+			Key:  key,
+			Tok:  token.DEFINE,
+			X:    goValue,
+			Body: &ast.BlockStmt{List: body},
+		},
+	}, err
+}
+
+// under a //wasm:jsMap directive maps are encoded as js Maps,
+// keys being encoded as values rather than property names so they keep their type
+//
+// generated encoder:
+// 	name := js.Global().Get("Map").New()
+// 	for nameKey := range goValue {
+// 		...
+// 		name.Call("set", ..., ...)
+// 	}
+func (gen *generator) encodeJSMap(
+	name *ast.Ident,
+	goValue ast.Expr,
+	nativeType *ast.MapType,
+	eltExpr ast.Expr,
+	eltEncoder []ast.Stmt,
+) (expr ast.Expr, encoder []ast.Stmt, err error) {
+	key := &ast.Ident{Name: name.Name + "Key"}
+	keyExpr, keyEncoder, err := gen.EncodeValue(&ast.Ident{Name: key.Name + "JS"}, key, nativeType.Key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Unencodable map key type %s: %v", types.ExprString(nativeType.Key), err)
+	}
+
+	body := append(keyEncoder, eltEncoder...)
+	body = append(body, &ast.ExprStmt{
+		X: &ast.CallExpr{
+			Fun: &ast.SelectorExpr{
+				X:   name,
+				Sel: &ast.Ident{Name: "Call"},
+			},
+			Args: []ast.Expr{
+				&ast.BasicLit{Kind: token.STRING, Value: `"set"`},
+				keyExpr,
+				eltExpr,
+			},
+		},
+	})
+
+	return name, []ast.Stmt{
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{name},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X: &ast.CallExpr{
+							Fun: &ast.SelectorExpr{
+								X: &ast.CallExpr{
+									Fun: &ast.SelectorExpr{
+										X:   gen.jsIdent(),
+										Sel: &ast.Ident{Name: "Global"},
+									},
+								},
+								Sel: &ast.Ident{Name: "Get"},
+							},
+							Args: []ast.Expr{&ast.BasicLit{Kind: token.STRING, Value: `"Map"`}},
+						},
+						Sel: &ast.Ident{Name: "New"},
+					},
+				},
+			},
+		},
+		&ast.RangeStmt{
+			Key:  key,
+			Tok:  token.DEFINE,
+			X:    goValue,
+			Body: &ast.BlockStmt{List: body},
+		},
+	}, nil
+}
+
+// returns js.ValueOf(goValue)
+func (gen *generator) jsValueOf(goValue ast.Expr) ast.Expr {
+	return &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   gen.jsIdent(),
+			Sel: &ast.Ident{Name: "ValueOf"},
+		},
+		Args: []ast.Expr{goValue},
+	}
+}
+
+// returns the map[string]any type js.ValueOf converts to objects
+func anyMapType() *ast.MapType {
+	return &ast.MapType{
+		Key:   &ast.Ident{Name: "string"},
+		Value: &ast.Ident{Name: "any"},
+	}
+}
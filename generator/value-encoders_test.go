@@ -0,0 +1,247 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEncodeValue(t *testing.T) {
+	src := `package main
+
+type Celsius float64
+
+func Temps() []Celsius {
+	return []Celsius{21.5, -3}
+}
+
+func Counts() map[string][]int {
+	return map[string][]int{"a": {1, 2}}
+}
+
+func Maybe(some bool) *int {
+	if !some {
+		return nil
+	}
+
+	n := 7
+	return &n
+}
+
+func Complex() complex128 {
+	return complex(1, -2)
+}
+`
+	script := `[JSON.stringify(Temps()), JSON.stringify(Counts()), Maybe(true), Maybe(false), JSON.stringify(Complex(), ["re", "im"])].join(",")`
+	got := runWasm(t, src, NewConfig(), script)
+	if want := `[21.5,-3],{"a":[1,2]},7,,{"re":1,"im":-2}`; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestLazySlices(t *testing.T) {
+	src := `package main
+
+var items = []int{1, 2, 3}
+
+func Items() []int {
+	return items
+}
+
+func SetItem(i, value int) {
+	items[i] = value
+}
+`
+	// elements are encoded as they're first read, so only those read after SetItem see its values
+	script := `const proxy = Items();
+const first = proxy[0];
+SetItem(0, 10);
+SetItem(1, 20);
+[first, proxy[0], proxy[1], proxy.length, Array.isArray(proxy), JSON.stringify(proxy), proxy.map((n) => n * 2).join(" ")].join(",")`
+	config := NewConfig()
+	config.LazySlices = true
+	got := runWasm(t, src, config, script)
+	if want := "1,1,20,3,true,[1,20,3],2 40 6"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestUnencodableResults(t *testing.T) {
+	for _, src := range []string{
+		`package main
+
+func Ticks() chan int {
+	return nil
+}
+`,
+		`package main
+
+func Ticks() (chan int, error) {
+	return nil, nil
+}
+`,
+	} {
+		_, err := tryGenerate(src, NewConfig())
+		if err == nil || !strings.Contains(err.Error(), "Unencodable result of Ticks") {
+			t.Errorf("Expected an unencodable result error, got %v", err)
+		}
+	}
+}
+
+func TestBoolPointerResults(t *testing.T) {
+	src := `package main
+
+func Flag(n int) *bool {
+	if n == 0 {
+		return nil
+	}
+	flag := n > 0
+	return &flag
+}
+
+type Answer struct {
+	Agreed *bool
+}
+
+func Answers() []Answer {
+	yes, no := true, false
+	return []Answer{{&yes}, {&no}, {nil}}
+}
+`
+	// nil pointers are returned as null, the tri-state a *bool holds
+	got := runWasm(t, src, nil, `JSON.stringify([Flag(1), Flag(-1), Flag(0)]) + " " + JSON.stringify(Answers())`)
+	if want := `[true,false,null] [{"Agreed":true},{"Agreed":false},{"Agreed":null}]`; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestStructRoundTrip(t *testing.T) {
+	src := `package main
+
+type Base struct {
+	ID int
+}
+
+type Item struct {
+	Base
+	Name  string
+	Price float64
+	Tags  []string
+}
+
+func Echo(item Item) Item {
+	return item
+}
+`
+	// fields are encoded under the keys they're resolved from, with those of embedded structs promoted
+	got := runWasm(t, src, NewConfig(), `JSON.stringify(Echo({ID: 7, Name: "bolt", Price: 0.25, Tags: ["m4"]}), ["ID", "Name", "Price", "Tags"])`)
+	if want := `{"ID":7,"Name":"bolt","Price":0.25,"Tags":["m4"]}`; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestDurationResults(t *testing.T) {
+	src := `package main
+
+import "time"
+
+func Timeout() time.Duration {
+	return 90 * time.Minute
+}
+
+//wasm:duration=string
+func Interval() time.Duration {
+	return 90 * time.Minute
+}
+`
+	got := runWasm(t, src, NewConfig(), `[typeof Timeout(), Timeout(), Interval()].join(",")`)
+	if want := "number,5400000000000,1h30m0s"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	_, err := tryGenerate(strings.Replace(src, "duration=string", "duration=hours", 1), NewConfig())
+	if err == nil {
+		t.Error("Expected an error for an invalid //wasm:duration value")
+	}
+}
+
+func TestReuseObject(t *testing.T) {
+	src := `package main
+
+type Point struct {
+	X, Y int
+}
+
+var next int
+
+//wasm:reuse
+func Next() *Point {
+	next++
+	return &Point{X: next, Y: -next}
+}
+`
+	// the same object is returned from every call, holding the latest result
+	script := `const first = Next(); const firstX = first.X; const second = Next();
+[first === second, firstX, second.X, second.Y].join(",")`
+	if got := runWasm(t, src, nil, script); got != "true,1,2,-2" {
+		t.Errorf("Expected true,1,2,-2, got %s", got)
+	}
+
+	_, err := tryGenerate(`package main
+
+//wasm:reuse
+func Count() int {
+	return 0
+}
+`, nil)
+	if err == nil {
+		t.Error("Expected an error reusing a non-struct result")
+	}
+}
+
+// compares returning a struct as a new js object on every call to reusing one
+func BenchmarkReuseObject(b *testing.B) {
+	src := `package main
+
+type Point struct {
+	X, Y, Z float64
+}
+
+func New() Point {
+	return Point{1, 2, 3}
+}
+
+//wasm:reuse
+func Reused() Point {
+	return Point{1, 2, 3}
+}
+`
+	benchWasm(b, src, nil, map[string]string{
+		"New":    `() => New()`,
+		"Reused": `() => Reused()`,
+	})
+}
+
+func TestJSMapResults(t *testing.T) {
+	src := `package main
+
+//wasm:jsMap
+func Squares(n int) map[int]string {
+	squares := make(map[int]string, n)
+	for i := 1; i <= n; i++ {
+		squares[i*i] = string(rune('a' + i - 1))
+	}
+	return squares
+}
+
+//wasm:jsMap
+func Lists() map[string][]int {
+	return map[string][]int{"odd": {1, 3}}
+}
+`
+	script := `const squares = Squares(3);
+[squares instanceof Map, squares.size, squares.get(4), squares.has("4"), [...squares.keys()].sort((a, b) => a - b).join(" "), JSON.stringify(Lists().get("odd"))].join(",")`
+	got := runWasm(t, src, nil, script)
+	if want := "true,3,b,false,1 4 9,[1,3]"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
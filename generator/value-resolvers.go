@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"go/ast"
 	"go/token"
+	"go/types"
 	"strconv"
+	"strings"
 )
 
 // returns an expression that casts jsValue to the given native type
@@ -15,6 +17,20 @@ func (gen *generator) ResolveValue(
 	nativeType ast.Expr,
 	dst ast.Expr,
 ) (ast.Expr, []ast.Stmt, error) {
+	if regType := gen.getRegisteredType(nativeType); regType != nil {
+		return gen.resolveRegistered(name, jsValue, nativeType, regType, dst)
+	}
+
+	switch nativeType.(type) {
+	case *ast.StarExpr, *ast.ArrayType, *ast.StructType, *ast.MapType:
+		if gen.config.MaxDepth > 0 && gen.depth >= gen.config.MaxDepth {
+			return gen.resolveTooDeep(name, jsValue, nativeType, dst)
+		}
+
+		gen.depth++
+		defer func() { gen.depth-- }()
+	}
+
 	switch nativeType := nativeType.(type) {
 	case *ast.Ident:
 		return gen.resolveIdent(name, jsValue, nativeType, dst)
@@ -24,10 +40,340 @@ func (gen *generator) ResolveValue(
 		return gen.resolveArray(name, jsValue, nativeType, dst)
 	case *ast.StructType:
 		return gen.resolveStruct(name, jsValue, nativeType, dst)
+	case *ast.MapType:
+		return gen.resolveMap(name, jsValue, nativeType, dst)
+	case *ast.SelectorExpr:
+		return gen.resolveSelector(nativeType)
+	case *ast.InterfaceType:
+		return gen.resolveInterface(name, jsValue, nativeType, dst)
+	case *ast.FuncType:
+		return gen.resolveFunc(name, jsValue, nativeType, dst)
+	case *ast.ChanType:
+		return gen.resolveChan(name, jsValue, nativeType, dst)
 	default:
+		return nil, nil, fmt.Errorf("Unrecognized native type %s (%T)", types.ExprString(nativeType), nativeType)
+	}
+}
+
+// empty interfaces (any) hold the js value as is, other interfaces must be registered
+// (see Config.RegisterType and Config.RegisterPrototypes)
+//
+// generated resolver:
+// 	jsValue
+func (gen *generator) resolveInterface(
+	name *ast.Ident,
+	jsValue ast.Expr,
+	nativeType *ast.InterfaceType,
+	dst ast.Expr,
+) (expr ast.Expr, resolver []ast.Stmt, err error) {
+	if nativeType.Methods.NumFields() > 0 {
+		return nil, nil, fmt.Errorf(
+			"No resolver for interface type %s, one may be added with Config.RegisterType or Config.RegisterPrototypes",
+			types.ExprString(nativeType),
+		)
+	}
+
+	if dst != nil {
+		return dst, []ast.Stmt{
+			&ast.AssignStmt{
+				Lhs: []ast.Expr{dst},
+				Tok: token.ASSIGN,
+				Rhs: []ast.Expr{jsValue},
+			},
+		}, nil
+	}
+
+	return jsValue, nil, nil
+}
+
+// resolves a js function into a go func of the given signature that calls it,
+// encoding its arguments to js and resolving the js result into its result type, if it has one
+//
+// generated resolver:
+// 	name := func(nameArg0 A) R {
+// 		nameResult := jsValue.Invoke(...)
+// 		...
+// 		return ...
+// 	}
+func (gen *generator) resolveFunc(
+	name *ast.Ident,
+	jsValue ast.Expr,
+	nativeType *ast.FuncType,
+	dst ast.Expr,
+) (expr ast.Expr, resolver []ast.Stmt, err error) {
+	if nativeType.Results.NumFields() > 1 {
+		return nil, nil, fmt.Errorf("Func types with more than one result can't be resolved")
+	}
+
+	var body []ast.Stmt
+	params := make([]*ast.Field, 0, nativeType.Params.NumFields())
+	jsArgs := make([]ast.Expr, 0, nativeType.Params.NumFields())
+	for _, param := range nativeType.Params.List {
+		if _, ok := param.Type.(*ast.Ellipsis); ok {
+			return nil, nil, fmt.Errorf("Func types with variadic parameters can't be resolved")
+		}
+
+		// the source parameter names may be absent, so each is named by position
+		count := len(param.Names)
+		if count == 0 {
+			count = 1
+		}
+
+		for i := 0; i < count; i++ {
+			arg := &ast.Ident{Name: name.Name + "Arg" + strconv.Itoa(len(params))}
+			params = append(params, &ast.Field{Names: []*ast.Ident{arg}, Type: param.Type})
+
+			jsArg, encoder, err := gen.EncodeValue(&ast.Ident{Name: arg.Name + "JS"}, arg, param.Type)
+			if err != nil {
+				return nil, nil, fmt.Errorf("Unencodable func parameter type %s: %v", types.ExprString(param.Type), err)
+			}
+
+			body = append(body, encoder...)
+			jsArgs = append(jsArgs, jsArg)
+		}
+	}
+
+	call := &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   jsValue,
+			Sel: &ast.Ident{Name: "Invoke"},
+		},
+		Args: jsArgs,
+	}
+
+	if nativeType.Results.NumFields() == 0 {
+		body = append(body, &ast.ExprStmt{X: call})
+	} else {
+		resultType := nativeType.Results.List[0].Type
+		result := &ast.Ident{Name: name.Name + "Result"}
+		body = append(body, &ast.AssignStmt{
+			Lhs: []ast.Expr{result},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{call},
+		})
+
+		resultExpr, resultResolver, err := gen.ResolveValue(&ast.Ident{Name: result.Name + "Go"}, result, resultType, nil)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Unresolved func result type %s: %v", types.ExprString(resultType), err)
+		}
+
+		body = append(body, resultResolver...)
+		body = append(body, &ast.ReturnStmt{Results: []ast.Expr{resultExpr}})
+	}
+
+	expr = &ast.FuncLit{
+		Type: &ast.FuncType{
+			Params:  &ast.FieldList{List: params},
+			Results: nativeType.Results,
+		},
+		Body: &ast.BlockStmt{List: body},
+	}
+
+	if dst == nil {
+		return name, gen.withResolverCount("func", []ast.Stmt{
+			&ast.AssignStmt{
+				Lhs: []ast.Expr{name},
+				Tok: token.DEFINE,
+				Rhs: []ast.Expr{expr},
+			},
+		}), nil
+	}
+
+	return dst, gen.withResolverCount("func", []ast.Stmt{
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{dst},
+			Tok: token.ASSIGN,
+			Rhs: []ast.Expr{expr},
+		},
+	}), nil
+}
+
+// resolves a js pull callback or iterator into a channel receiving the values it produces,
+// the callback returning {value} objects and then {done: true} or undefined, as iterators' next does.
+// each value is pulled once the previous one has been received, and the channel is closed when done
+//
+// generated resolver:
+// 	name := make(chan T)
+// 	go func() {
+// 		defer close(name)
+// 		for {
+// 			nameNext, ok := wasmPull(jsValue)
+// 			if !ok {
+// 				return
+// 			}
+// 			...
+// 			name <- ...
+// 		}
+// 	}()
+func (gen *generator) resolveChan(
+	name *ast.Ident,
+	jsValue ast.Expr,
+	nativeType *ast.ChanType,
+	dst ast.Expr,
+) (expr ast.Expr, resolver []ast.Stmt, err error) {
+	if nativeType.Dir == ast.SEND {
+		return nil, nil, fmt.Errorf("Send-only channels can't be resolved")
+	}
+
+	next := &ast.Ident{Name: name.Name + "Next"}
+	ok := &ast.Ident{Name: "ok"}
+	eltExpr, eltResolver, err := gen.ResolveValue(&ast.Ident{Name: name.Name + "Elt"}, next, nativeType.Value, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Unresolved channel element type %v: %v", types.ExprString(nativeType.Value), err)
+	}
+
+	gen.useHelper("pull")
+	pullLoop := []ast.Stmt{
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{next, ok},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun:  &ast.Ident{Name: "wasmPull"},
+					Args: []ast.Expr{jsValue},
+				},
+			},
+		},
+		&ast.IfStmt{
+			Cond: &ast.UnaryExpr{Op: token.NOT, X: ok},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{&ast.ReturnStmt{}},
+			},
+		},
+	}
+	pullLoop = append(pullLoop, eltResolver...)
+	pullLoop = append(pullLoop, &ast.SendStmt{Chan: name, Value: eltExpr})
+
+	resolver = []ast.Stmt{
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{name},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun:  &ast.Ident{Name: "make"},
+					Args: []ast.Expr{&ast.ChanType{Dir: ast.SEND | ast.RECV, Value: nativeType.Value}},
+				},
+			},
+		},
+		&ast.GoStmt{
+			Call: &ast.CallExpr{
+				Fun: &ast.FuncLit{
+					Type: &ast.FuncType{Params: &ast.FieldList{}},
+					Body: &ast.BlockStmt{
+						List: []ast.Stmt{
+							&ast.DeferStmt{
+								Call: &ast.CallExpr{
+									Fun:  &ast.Ident{Name: "close"},
+									Args: []ast.Expr{name},
+								},
+							},
+							&ast.ForStmt{Body: &ast.BlockStmt{List: pullLoop}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if dst != nil {
+		// declared in its own block so channels of several fields don't collide
+		resolver = []ast.Stmt{
+			&ast.BlockStmt{
+				List: append(resolver, &ast.AssignStmt{
+					Lhs: []ast.Expr{dst},
+					Tok: token.ASSIGN,
+					Rhs: []ast.Expr{name},
+				}),
+			},
+		}
+
+		return dst, gen.withResolverCount("chan", resolver), nil
+	}
+
+	return name, gen.withResolverCount("chan", resolver), nil
+}
+
+// types from other packages are only resolved when registered (see builtinTypes and Config.RegisterType),
+// reaching here means the type has no resolver
+func (gen *generator) resolveSelector(nativeType *ast.SelectorExpr) (ast.Expr, []ast.Stmt, error) {
+	return nil, nil, fmt.Errorf(
+		"No resolver for type %s, one may be added with Config.RegisterType",
+		types.ExprString(nativeType),
+	)
+}
+
+func (gen *generator) resolveRegistered(
+	name *ast.Ident,
+	jsValue ast.Expr,
+	nativeType ast.Expr,
+	regType *registeredType,
+	dst ast.Expr,
+) (expr ast.Expr, resolver []ast.Stmt, err error) {
+	expr, resolver, err = regType.resolver(gen, name, jsValue, nativeType)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, path := range regType.imports {
+		gen.imports[path] = true
+	}
+
+	resolver = gen.withResolverCount(types.ExprString(nativeType), resolver)
+
+	if dst != nil {
+		resolver = append(resolver, &ast.AssignStmt{
+			Lhs: []ast.Expr{dst},
+			Tok: token.ASSIGN,
+			Rhs: []ast.Expr{expr},
+		})
+
+		expr = dst
+	}
+
+	return expr, resolver, err
+}
+
+// resolves a value nested deeper than the configured MaxDepth,
+// leaving it zero when absent and throwing otherwise
+//
+// generated resolver:
+// 	var name T
+// 	if !jsValue.IsUndefined() && !jsValue.IsNull() {
+// 		panic(js.Global().Get("Error").New("Maximum resolution depth of n exceeded"))
+// 	}
+func (gen *generator) resolveTooDeep(
+	name *ast.Ident,
+	jsValue ast.Expr,
+	nativeType ast.Expr,
+	dst ast.Expr,
+) (expr ast.Expr, resolver []ast.Stmt, err error) {
+	if dst == nil {
+		resolver = append(resolver, &ast.DeclStmt{
+			Decl: &ast.GenDecl{
+				Tok: token.VAR,
+				Specs: []ast.Spec{
+					&ast.ValueSpec{
+						Names: []*ast.Ident{name},
+						Type:  nativeType,
+					},
+				},
+			},
+		})
 
-		panic(fmt.Errorf("Unrecognized native type : %v", nativeType))
+		dst = name
 	}
+
+	return dst, append(resolver, &ast.IfStmt{
+		Cond: isPresentExpr(jsValue),
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				gen.throwStmt(&ast.BasicLit{
+					Kind:  token.STRING,
+					Value: strconv.Quote(fmt.Sprintf("Maximum resolution depth of %d exceeded", gen.config.MaxDepth)),
+				}),
+			},
+		},
+	}), err
 }
 
 func (gen *generator) resolveIdent(
@@ -36,37 +382,86 @@ func (gen *generator) resolveIdent(
 	nativeType *ast.Ident,
 	dst ast.Expr,
 ) (expr ast.Expr, resolver []ast.Stmt, err error) {
-	var method, typeCast string
+	var method, helperFunc, typeCast string
 	switch typeStr := nativeType.String(); typeStr {
 	case "bool":
 		method = "Bool"
 	case "string":
 		method = "String"
+		if gen.config.StringifyValues {
+			helperFunc = "wasmStringify"
+			gen.useHelper("stringify")
+		}
 	case "int", "int8", "int16", "int32", "rune", "int64",
 		"uint", "uint8", "byte", "uint16", "uint32", "uint64", "uintptr":
 		method = "Int"
 		if typeStr != "int" {
 			typeCast = typeStr
 		}
+
+		if gen.config.BigIntFor64 && (typeStr == "int64" || typeStr == "uint64") {
+			// values beyond 2^53 can be passed exactly as bigints
+			helperFunc = "wasmResolve" + strings.ToUpper(typeStr[:1]) + typeStr[1:]
+			typeCast = ""
+			gen.useHelper("bigint")
+		}
 	case "float32", "float64":
 		method = "Float"
 		if typeStr != "float64" {
 			typeCast = typeStr
 		}
+	case "error":
+		// js Errors resolve to their message, null and undefined to nil
+		helperFunc = "wasmResolveError"
+		gen.useHelper("resolveError")
+	case "any":
+		return gen.resolveInterface(name, jsValue, &ast.InterfaceType{Methods: &ast.FieldList{}}, dst)
+	case "complex64", "complex128":
+		helperFunc = "wasmResolveComplex"
+		gen.useHelper("complex")
+		if gen.config.PolarComplex {
+			helperFunc = "wasmResolvePolarComplex"
+			gen.useHelper("polar")
+		}
+
+		if typeStr != "complex128" {
+			typeCast = typeStr
+		}
 	default:
-		nativeType, err := gen.getTypeAlias(typeStr)
-		if err != nil {
-			return nil, nil, fmt.Errorf("Unresolved identifier: %v", err)
+		if strings.HasSuffix(typeStr, "LazyWasm") && typeStr != "LazyWasm" {
+			return gen.resolveLazy(name, jsValue, strings.TrimSuffix(typeStr, "LazyWasm"), dst)
+		}
+
+		if gen.resolving[typeStr] {
+			return gen.resolveNamedFunc(name, jsValue, typeStr, dst)
+		}
+
+		if gen.config.EmitNamedResolvers && gen.eltValidator == nil && gen.isComposite(typeStr) {
+			// types that can't be resolved outside the wrapper (e.g. with fields from this) are inlined
+			if expr, resolver, err := gen.resolveNamedFunc(name, jsValue, typeStr, dst); err == nil {
+				return expr, resolver, err
+			}
 		}
 
-		return gen.ResolveValue(&ast.Ident{Name: typeStr}, jsValue, nativeType, dst)
+		return gen.resolveNamed(name, jsValue, typeStr, dst)
 	}
 
-	expr = &ast.CallExpr{
-		Fun: &ast.SelectorExpr{
-			X:   jsValue,
-			Sel: &ast.Ident{Name: method},
-		},
+	if count := gen.countResolver(nativeType.Name); count != nil {
+		resolver = append(resolver, count)
+	}
+
+	if helperFunc != "" {
+		expr = &ast.CallExpr{
+			Fun:  &ast.Ident{Name: helperFunc},
+			Args: []ast.Expr{jsValue},
+		}
+	} else {
+		expr = &ast.CallExpr{
+			Fun: &ast.SelectorExpr{
+				X:   jsValue,
+				Sel: &ast.Ident{Name: method},
+			},
+		}
 	}
 
 	if typeCast != "" {
@@ -89,88 +484,645 @@ func (gen *generator) resolveIdent(
 	return expr, resolver, err
 }
 
-func (gen *generator) resolvePointer(
+// resolves a type declared in the current package from its underlying type.
+// named structs may start from their declared defaults and be checked by their Validate method
+func (gen *generator) resolveNamed(
 	name *ast.Ident,
 	jsValue ast.Expr,
-	nativeType *ast.StarExpr,
+	typeStr string,
 	dst ast.Expr,
 ) (expr ast.Expr, resolver []ast.Stmt, err error) {
-	if dst == nil {
-		dst = name
+	gen.resolving[typeStr] = true
+	defer delete(gen.resolving, typeStr)
+
+	nativeType, err := gen.getTypeAlias(typeStr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Unresolved identifier: %v", err)
+	}
+
+	structType, isStruct := nativeType.(*ast.StructType)
+	if isStruct {
+		structName := gen.structName
+		gen.structName = typeStr
+		defer func() { gen.structName = structName }()
+	}
+	if isStruct && gen.config.MergeDefaults && dst == nil {
+		if defaultValue := gen.getDefaultValue(typeStr); defaultValue != nil {
+			// start from the type's defaults and merge the present js fields over them
+			expr, resolver, err = gen.resolveStruct(name, jsValue, structType, name)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			resolver = append([]ast.Stmt{
+				&ast.AssignStmt{
+					Lhs: []ast.Expr{name},
+					Tok: token.DEFINE,
+					Rhs: []ast.Expr{defaultValue},
+				},
+			}, resolver...)
+		}
+	}
+
+	validate := isStruct && gen.hasValidateMethod(typeStr)
+	if expr == nil && isStruct && dst == nil {
+		// declared with the named type so its Validate method can be called,
+		// and its address taken as a pointer to the named type
 		resolver = append(resolver, &ast.DeclStmt{
 			Decl: &ast.GenDecl{
 				Tok: token.VAR,
 				Specs: []ast.Spec{
 					&ast.ValueSpec{
 						Names: []*ast.Ident{name},
-						Type:  nativeType,
+						Type:  &ast.Ident{Name: typeStr},
 					},
 				},
 			},
 		})
+
+		dst = name
 	}
 
-	_, eltResolver, err := gen.ResolveValue(
-		&ast.Ident{Name: name.Name + "Elt"},
-		jsValue,
-		nativeType.X,
-		dst,
-	)
-	if err != nil {
-		return nil, nil, fmt.Errorf("Unresolved pointer element type %v: %v", nativeType.X, err)
+	if basic, ok := nativeType.(*ast.Ident); ok && expr == nil {
+		// named basic types are resolved as their underlying type and converted:
+		// 	T(jsValue.String())
+		var basicResolver []ast.Stmt
+		expr, basicResolver, err = gen.ResolveValue(name, jsValue, basic, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		expr = &ast.CallExpr{Fun: &ast.Ident{Name: typeStr}, Args: []ast.Expr{expr}}
+		resolver = append(resolver, basicResolver...)
+		if dst != nil {
+			resolver = append(resolver, &ast.AssignStmt{
+				Lhs: []ast.Expr{dst},
+				Tok: token.ASSIGN,
+				Rhs: []ast.Expr{expr},
+			})
+
+			expr = dst
+		}
 	}
 
-	return dst, append(
-		resolver,
-		&ast.IfStmt{
+	if expr == nil {
+		var structResolver []ast.Stmt
+		expr, structResolver, err = gen.ResolveValue(name, jsValue, nativeType, dst)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		resolver = append(resolver, structResolver...)
+	}
+
+	if validate {
+		// structs with a Validate() error method are checked once resolved:
+		// 	if err := name.Validate(); err != nil {
+		// 		panic(js.Global().Get("Error").New(err.Error()))
+		// 	}
+		errIdent := &ast.Ident{Name: "err"}
+		resolver = append(resolver, &ast.IfStmt{
 			Init: &ast.AssignStmt{
-				Lhs: []ast.Expr{&ast.Ident{Name: "jsType"}},
+				Lhs: []ast.Expr{errIdent},
 				Tok: token.DEFINE,
 				Rhs: []ast.Expr{
 					&ast.CallExpr{
 						Fun: &ast.SelectorExpr{
-							X:   jsValue,
-							Sel: &ast.Ident{Name: "Type"},
+							X:   expr,
+							Sel: &ast.Ident{Name: "Validate"},
 						},
 					},
 				},
 			},
-			Cond: &ast.BinaryExpr{
-				X: &ast.BinaryExpr{
-					X:  &ast.Ident{Name: "jsType"},
-					Op: token.NEQ,
-					Y: &ast.SelectorExpr{
-						X:   &ast.Ident{Name: "js"},
-						Sel: &ast.Ident{Name: "TypeUndefined"},
-					},
-				},
-				Op: token.LOR,
-				Y: &ast.BinaryExpr{
-					X:  &ast.Ident{Name: "jsType"},
-					Op: token.NEQ,
-					Y: &ast.SelectorExpr{
-						X:   &ast.Ident{Name: "js"},
-						Sel: &ast.Ident{Name: "TypeNull"},
-					},
-				},
-			},
+			Cond: &ast.BinaryExpr{X: errIdent, Op: token.NEQ, Y: &ast.Ident{Name: "nil"}},
 			Body: &ast.BlockStmt{
-				List: eltResolver,
+				List: []ast.Stmt{
+					gen.throwStmt(&ast.CallExpr{
+						Fun: &ast.SelectorExpr{
+							X:   errIdent,
+							Sel: &ast.Ident{Name: "Error"},
+						},
+					}),
+				},
 			},
-		},
-	), err
+		})
+	}
+
+	return expr, resolver, err
 }
 
-func (gen *generator) resolveArray(
+// resolves a named type through a generated function rather than inline,
+// either from within its own resolution (e.g. a Node's Next *Node field), which would never terminate inline,
+// or for every use with EmitNamedResolvers.
+// the function is generated once per type, independent of the wrapper it's first reached from
+//
+// with MaxDepth the function is passed the depth it's called at, so recursive types are limited too
+//
+// generated resolver:
+// 	wasmResolveNode(jsValue)
+// and function:
+// 	func wasmResolveNode(value js.Value) Node {
+// 		...
+// 		return resolved
+// 	}
+func (gen *generator) resolveNamedFunc(
 	name *ast.Ident,
 	jsValue ast.Expr,
-	nativeType *ast.ArrayType,
+	typeStr string,
 	dst ast.Expr,
 ) (expr ast.Expr, resolver []ast.Stmt, err error) {
-	lenExpr := nativeType.Len
-	if lenExpr == nil { // if the native type represents a slice
-		// create a variable to hold the runtime length
-		lenExpr = &ast.Ident{Name: name.Name + "Len"}
+	funcName := "wasmResolve" + strings.ToUpper(typeStr[:1]) + typeStr[1:]
+	if _, ok := gen.aliasResolvers[typeStr]; !ok {
+		// reserved first so the type's own recursion calls the function being generated
+		gen.aliasResolvers[typeStr] = nil
+
+		params := []*ast.Field{
+			{
+				Names: []*ast.Ident{{Name: "value"}},
+				Type: &ast.SelectorExpr{
+					X:   gen.jsIdent(),
+					Sel: &ast.Ident{Name: "Value"},
+				},
+			},
+		}
+		var depthParam ast.Expr
+		if gen.config.MaxDepth > 0 {
+			depthParam = &ast.Ident{Name: "depth"}
+			params = append(params, &ast.Field{
+				Names: []*ast.Ident{depthParam.(*ast.Ident)},
+				Type:  &ast.Ident{Name: "int"},
+			})
+		}
+
+		directives, this, eltValidator, depth, resolving, outerDepthParam := gen.directives, gen.this, gen.eltValidator, gen.depth, gen.resolving, gen.depthParam
+		gen.directives, gen.this, gen.eltValidator, gen.depth, gen.resolving, gen.depthParam = nil, nil, nil, 0, make(map[string]bool), depthParam
+		resolved, funcResolver, err := gen.resolveNamed(&ast.Ident{Name: "resolved"}, &ast.Ident{Name: "value"}, typeStr, nil)
+		if err == nil && depthParam != nil {
+			// values reached deeper than MaxDepth are left zero, or throw when present:
+			// 	if depth >= n {
+			// 		var resolved Node
+			// 		if !value.IsUndefined() && !value.IsNull() {
+			// 			panic(js.Global().Get("Error").New("Maximum resolution depth of n exceeded"))
+			// 		}
+			// 		return resolved
+			// 	}
+			var tooDeep ast.Expr
+			var tooDeepResolver []ast.Stmt
+			tooDeep, tooDeepResolver, err = gen.resolveTooDeep(&ast.Ident{Name: "resolved"}, &ast.Ident{Name: "value"}, &ast.Ident{Name: typeStr}, nil)
+			funcResolver = append([]ast.Stmt{
+				&ast.IfStmt{
+					Cond: &ast.BinaryExpr{
+						X:  depthParam,
+						Op: token.GEQ,
+						Y:  &ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(gen.config.MaxDepth)},
+					},
+					Body: &ast.BlockStmt{
+						List: append(tooDeepResolver, &ast.ReturnStmt{Results: []ast.Expr{tooDeep}}),
+					},
+				},
+			}, funcResolver...)
+		}
+		gen.directives, gen.this, gen.eltValidator, gen.depth, gen.resolving, gen.depthParam = directives, this, eltValidator, depth, resolving, outerDepthParam
+		if err != nil {
+			delete(gen.aliasResolvers, typeStr)
+			return nil, nil, err
+		}
+
+		gen.aliasResolvers[typeStr] = &ast.FuncDecl{
+			Name: &ast.Ident{Name: funcName},
+			Type: &ast.FuncType{
+				Params: &ast.FieldList{List: params},
+				Results: &ast.FieldList{
+					List: []*ast.Field{
+						{Type: &ast.Ident{Name: typeStr}},
+					},
+				},
+			},
+			Body: &ast.BlockStmt{
+				List: append(funcResolver, &ast.ReturnStmt{Results: []ast.Expr{resolved}}),
+			},
+		}
+	}
+
+	args := []ast.Expr{jsValue}
+	if gen.config.MaxDepth > 0 {
+		// the depth the value is at, relative to the depth of the function resolving it if any:
+		// 	wasmResolveNode(jsValue, depth+1)
+		var depthArg ast.Expr = &ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(gen.depth)}
+		if gen.depthParam != nil && gen.depth == 0 {
+			depthArg = gen.depthParam
+		} else if gen.depthParam != nil {
+			depthArg = &ast.BinaryExpr{X: gen.depthParam, Op: token.ADD, Y: depthArg}
+		}
+		args = append(args, depthArg)
+	}
+
+	expr = &ast.CallExpr{
+		Fun:  &ast.Ident{Name: funcName},
+		Args: args,
+	}
+	if dst != nil {
+		return dst, []ast.Stmt{
+			&ast.AssignStmt{
+				Lhs: []ast.Expr{dst},
+				Tok: token.ASSIGN,
+				Rhs: []ast.Expr{expr},
+			},
+		}, nil
+	}
+
+	return expr, nil, nil
+}
+
+// resolves a TLazyWasm value, T being a struct type of the current package, whose fields are each resolved
+// from the js object the first time their getter is called, for functions reading few fields of large structs.
+// the type and its getters are generated once per struct type
+//
+// generated resolver:
+// 	TLazyWasm{value: jsValue}
+// and type:
+// 	type TLazyWasm struct {
+// 		value    js.Value
+// 		fields   T
+// 		resolved [n]bool
+// 	}
+//
+// 	func (lazy *TLazyWasm) Field() FieldType {
+// 		if !lazy.resolved[0] {
+// 			value := lazy.value.Get("Field")
+// 			lazy.fields.Field = value.Int()
+// 			lazy.resolved[0] = true
+// 		}
+//
+// 		return lazy.fields.Field
+// 	}
+func (gen *generator) resolveLazy(
+	name *ast.Ident,
+	jsValue ast.Expr,
+	typeStr string,
+	dst ast.Expr,
+) (expr ast.Expr, resolver []ast.Stmt, err error) {
+	lazyName := typeStr + "LazyWasm"
+	if _, ok := gen.lazyTypes[lazyName]; !ok {
+		decls, err := gen.lazyTypeDecls(typeStr)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		gen.lazyTypes[lazyName] = decls
+	}
+
+	expr = &ast.CompositeLit{
+		Type: &ast.Ident{Name: lazyName},
+		Elts: []ast.Expr{
+			&ast.KeyValueExpr{Key: &ast.Ident{Name: "value"}, Value: jsValue},
+		},
+	}
+	if dst != nil {
+		return dst, []ast.Stmt{
+			&ast.AssignStmt{
+				Lhs: []ast.Expr{dst},
+				Tok: token.ASSIGN,
+				Rhs: []ast.Expr{expr},
+			},
+		}, nil
+	}
+
+	return expr, nil, nil
+}
+
+// returns the declarations of the lazy type of the given struct type and its field getters
+func (gen *generator) lazyTypeDecls(typeStr string) ([]ast.Decl, error) {
+	structType, ok := gen.underlyingType(&ast.Ident{Name: typeStr}).(*ast.StructType)
+	if !ok {
+		return nil, fmt.Errorf("Lazy type %sLazyWasm requires %s to be a struct type of the current package", typeStr, typeStr)
+	}
+
+	// getters are resolved outside any wrapper, as resolveNamedFunc resolves its types
+	directives, this, eltValidator, depth, resolving := gen.directives, gen.this, gen.eltValidator, gen.depth, gen.resolving
+	gen.directives, gen.this, gen.eltValidator, gen.depth, gen.resolving = nil, nil, nil, 0, make(map[string]bool)
+	defer func() {
+		gen.directives, gen.this, gen.eltValidator, gen.depth, gen.resolving = directives, this, eltValidator, depth, resolving
+	}()
+
+	lazy := &ast.Ident{Name: "lazy"}
+	lazyType := &ast.Ident{Name: typeStr + "LazyWasm"}
+	fields := &ast.SelectorExpr{X: lazy, Sel: &ast.Ident{Name: "fields"}}
+	var getters []ast.Decl
+	for _, field := range structType.Fields.List {
+		tag, err := parseFieldTag(field)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, fieldName := range field.Names {
+			if _, ok := tag.options["computed"]; ok || tag.name == "-" || !fieldName.IsExported() {
+				continue
+			}
+
+			resolved := &ast.IndexExpr{
+				X:     &ast.SelectorExpr{X: lazy, Sel: &ast.Ident{Name: "resolved"}},
+				Index: &ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(len(getters))},
+			}
+			// the property is read once, by the first call
+			fieldValue := &ast.Ident{Name: "value"}
+			fieldDst := &ast.SelectorExpr{X: fields, Sel: &ast.Ident{Name: fieldName.Name}}
+
+			fieldResolver := []ast.Stmt{
+				&ast.AssignStmt{
+					Lhs: []ast.Expr{fieldValue},
+					Tok: token.DEFINE,
+					Rhs: []ast.Expr{
+						&ast.CallExpr{
+							Fun: &ast.SelectorExpr{
+								X:   &ast.SelectorExpr{X: lazy, Sel: &ast.Ident{Name: "value"}},
+								Sel: &ast.Ident{Name: "Get"},
+							},
+							Args: []ast.Expr{
+								&ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(gen.propertyName(tag, fieldName))},
+							},
+						},
+					},
+				},
+			}
+			if _, ok := tag.options["required"]; ok {
+				fieldResolver = append(fieldResolver, &ast.IfStmt{
+					Cond: &ast.CallExpr{
+						Fun: &ast.SelectorExpr{
+							X:   fieldValue,
+							Sel: &ast.Ident{Name: "IsUndefined"},
+						},
+					},
+					Body: &ast.BlockStmt{
+						List: []ast.Stmt{
+							gen.throwStmt(&ast.BasicLit{
+								Kind:  token.STRING,
+								Value: strconv.Quote("Missing required field " + gen.propertyName(tag, fieldName)),
+							}),
+						},
+					},
+				})
+			}
+
+			_, valueResolver, err := gen.ResolveValue(&ast.Ident{Name: "resolved" + fieldName.Name}, fieldValue, field.Type, fieldDst)
+			if err != nil {
+				return nil, fmt.Errorf("Unresolved struct field type %v: %v", types.ExprString(field.Type), err)
+			}
+
+			fieldResolver = append(append(fieldResolver, valueResolver...), &ast.AssignStmt{
+				Lhs: []ast.Expr{resolved},
+				Tok: token.ASSIGN,
+				Rhs: []ast.Expr{&ast.Ident{Name: "true"}},
+			})
+
+			getters = append(getters, &ast.FuncDecl{
+				Recv: &ast.FieldList{
+					List: []*ast.Field{
+						{
+							Names: []*ast.Ident{lazy},
+							Type:  &ast.StarExpr{X: lazyType},
+						},
+					},
+				},
+				Name: &ast.Ident{Name: fieldName.Name},
+				Type: &ast.FuncType{
+					Params: &ast.FieldList{},
+					Results: &ast.FieldList{
+						List: []*ast.Field{{Type: field.Type}},
+					},
+				},
+				Body: &ast.BlockStmt{
+					List: []ast.Stmt{
+						&ast.IfStmt{
+							Cond: &ast.UnaryExpr{Op: token.NOT, X: resolved},
+							Body: &ast.BlockStmt{List: fieldResolver},
+						},
+						&ast.ReturnStmt{Results: []ast.Expr{fieldDst}},
+					},
+				},
+			})
+		}
+	}
+
+	return append([]ast.Decl{
+		&ast.GenDecl{
+			Tok: token.TYPE,
+			Specs: []ast.Spec{
+				&ast.TypeSpec{
+					Name: lazyType,
+					Type: &ast.StructType{
+						Fields: &ast.FieldList{
+							List: []*ast.Field{
+								{
+									Names: []*ast.Ident{{Name: "value"}},
+									Type: &ast.SelectorExpr{
+										X:   gen.jsIdent(),
+										Sel: &ast.Ident{Name: "Value"},
+									},
+								},
+								{
+									Names: []*ast.Ident{{Name: "fields"}},
+									Type:  &ast.Ident{Name: typeStr},
+								},
+								{
+									Names: []*ast.Ident{{Name: "resolved"}},
+									Type: &ast.ArrayType{
+										Len: &ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(len(getters))},
+										Elt: &ast.Ident{Name: "bool"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}, getters...), nil
+}
+
+func (gen *generator) resolvePointer(
+	name *ast.Ident,
+	jsValue ast.Expr,
+	nativeType *ast.StarExpr,
+	dst ast.Expr,
+) (expr ast.Expr, resolver []ast.Stmt, err error) {
+	if dst == nil {
+		dst = name
+		resolver = append(resolver, &ast.DeclStmt{
+			Decl: &ast.GenDecl{
+				Tok: token.VAR,
+				Specs: []ast.Spec{
+					&ast.ValueSpec{
+						Names: []*ast.Ident{name},
+						Type:  nativeType,
+					},
+				},
+			},
+		})
+	}
+
+	// the element is resolved into its own variable for dst to point to:
+	// 	var nameElt T
+	// 	...
+	// 	dst = &nameElt
+	elt := &ast.Ident{Name: name.Name + "Elt"}
+	_, eltResolver, err := gen.ResolveValue(elt, jsValue, nativeType.X, elt)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Unresolved pointer element type %v: %v", nativeType.X, err)
+	}
+
+	eltResolver = append([]ast.Stmt{
+		&ast.DeclStmt{
+			Decl: &ast.GenDecl{
+				Tok: token.VAR,
+				Specs: []ast.Spec{
+					&ast.ValueSpec{
+						Names: []*ast.Ident{elt},
+						Type:  nativeType.X,
+					},
+				},
+			},
+		},
+	}, eltResolver...)
+	eltResolver = append(eltResolver, &ast.AssignStmt{
+		Lhs: []ast.Expr{dst},
+		Tok: token.ASSIGN,
+		Rhs: []ast.Expr{&ast.UnaryExpr{Op: token.AND, X: elt}},
+	})
+
+	resolver = gen.withResolverCount("pointer", resolver)
+
+	return dst, append(
+		resolver,
+		&ast.IfStmt{
+			Init: &ast.AssignStmt{
+				Lhs: []ast.Expr{&ast.Ident{Name: "jsType"}},
+				Tok: token.DEFINE,
+				Rhs: []ast.Expr{
+					&ast.CallExpr{
+						Fun: &ast.SelectorExpr{
+							X:   jsValue,
+							Sel: &ast.Ident{Name: "Type"},
+						},
+					},
+				},
+			},
+			Cond: &ast.BinaryExpr{
+				X: &ast.BinaryExpr{
+					X:  &ast.Ident{Name: "jsType"},
+					Op: token.NEQ,
+					Y: &ast.SelectorExpr{
+						X:   gen.jsIdent(),
+						Sel: &ast.Ident{Name: "TypeUndefined"},
+					},
+				},
+				Op: token.LAND,
+				Y: &ast.BinaryExpr{
+					X:  &ast.Ident{Name: "jsType"},
+					Op: token.NEQ,
+					Y: &ast.SelectorExpr{
+						X:   gen.jsIdent(),
+						Sel: &ast.Ident{Name: "TypeNull"},
+					},
+				},
+			},
+			Body: &ast.BlockStmt{
+				List: eltResolver,
+			},
+		},
+	), err
+}
+
+// returns the number of elements of a fixed array of length arrayLen to read from the js array,
+// handling js arrays of another length by the configured ArrayLengthMismatch policy
+//
+// generated resolver:
+// 	nameLen := jsValue.Length()
+// 	if nameLen < n {
+// 		panic(js.Global().Get("Error").New(fmt.Sprintf("Expected an array of %d elements, got %d", n, nameLen)))
+// 	}
+// 	if nameLen > n {
+// 		nameLen = n
+// 	}
+func (gen *generator) fixedArrayLen(name *ast.Ident, jsValue ast.Expr, arrayLen ast.Expr) (ast.Expr, []ast.Stmt) {
+	length := &ast.Ident{Name: name.Name + "Len"}
+	resolver := []ast.Stmt{
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{length},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X:   jsValue,
+						Sel: &ast.Ident{Name: "Length"},
+					},
+				},
+			},
+		},
+	}
+
+	mismatch := func(op token.Token) ast.Stmt {
+		gen.imports["fmt"] = true
+		return &ast.IfStmt{
+			Cond: &ast.BinaryExpr{X: length, Op: op, Y: arrayLen},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					gen.throwStmt(&ast.CallExpr{
+						Fun: &ast.SelectorExpr{
+							X:   &ast.Ident{Name: "fmt"},
+							Sel: &ast.Ident{Name: "Sprintf"},
+						},
+						Args: []ast.Expr{
+							&ast.BasicLit{Kind: token.STRING, Value: `"Expected an array of %d elements, got %d"`},
+							arrayLen,
+							length,
+						},
+					}),
+				},
+			},
+		}
+	}
+
+	switch gen.config.ArrayLengthMismatch {
+	case StrictArrays:
+		return length, append(resolver, mismatch(token.NEQ))
+	case TruncateArrays:
+		resolver = append(resolver, mismatch(token.LSS))
+	}
+
+	// longer js arrays are read up to the array's length, shorter ones leaving the rest zero
+	return length, append(resolver, &ast.IfStmt{
+		Cond: &ast.BinaryExpr{X: length, Op: token.GTR, Y: arrayLen},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.AssignStmt{
+					Lhs: []ast.Expr{length},
+					Tok: token.ASSIGN,
+					Rhs: []ast.Expr{arrayLen},
+				},
+			},
+		},
+	})
+}
+
+func (gen *generator) resolveArray(
+	name *ast.Ident,
+	jsValue ast.Expr,
+	nativeType *ast.ArrayType,
+	dst ast.Expr,
+) (expr ast.Expr, resolver []ast.Stmt, err error) {
+	if elt, ok := nativeType.Elt.(*ast.Ident); ok && elt.Name == "rune" && nativeType.Len == nil {
+		return gen.resolveRunes(name, jsValue, nativeType, dst)
+	}
+
+	lenExpr := nativeType.Len
+	if lit, ok := lenExpr.(*ast.BasicLit); ok {
+		// without its source position, so the loop condition isn't split across lines
+		lenExpr = &ast.BasicLit{Kind: lit.Kind, Value: lit.Value}
+	}
+	if lenExpr == nil { // if the native type represents a slice
+		// create a variable to hold the runtime length
+		lenExpr = &ast.Ident{Name: name.Name + "Len"}
 
 		// resolve the runtime length
 		resolver = append(resolver, &ast.AssignStmt{
@@ -201,6 +1153,12 @@ func (gen *generator) resolveArray(
 				},
 			})
 		} else {
+			// lengths may be constants of other packages (e.g. [pkg.N]T), imported for the declaration
+			err = gen.addTypeImports(nativeType.Len)
+			if err != nil {
+				return nil, nil, err
+			}
+
 			// declare a new array and add it to the resolver
 			resolver = append(resolver, &ast.DeclStmt{
 				Decl: &ast.GenDecl{
@@ -217,130 +1175,1816 @@ func (gen *generator) resolveArray(
 
 		// set dst to the newly declared destination
 		dst = name
+	} else if nativeType.Len == nil {
+		// slices held by fields and elements are allocated in place:
+		// 	dst = make([]T, nameLen)
+		resolver = append(resolver, &ast.AssignStmt{
+			Lhs: []ast.Expr{dst},
+			Tok: token.ASSIGN,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun:  &ast.Ident{Name: "make"},
+					Args: []ast.Expr{nativeType, lenExpr},
+				},
+			},
+		})
+	}
+
+	if _, ok := lenExpr.(*ast.BasicLit); !ok && nativeType.Len != nil {
+		// constant lengths (e.g. N or pkg.N) are read from the array itself,
+		// so the loop needs neither their package nor their source position:
+		// 	for nameIdx := 0; nameIdx < len(dst); nameIdx++ {
+		lenExpr = &ast.CallExpr{Fun: &ast.Ident{Name: "len"}, Args: []ast.Expr{dst}}
+	}
+
+	if nativeType.Len != nil {
+		var lenResolver []ast.Stmt
+		lenExpr, lenResolver = gen.fixedArrayLen(name, jsValue, lenExpr)
+		resolver = append(resolver, lenResolver...)
 	}
 
+	// the validator only applies to the elements of this array, not to arrays nested in them
+	validator := gen.eltValidator
+	gen.eltValidator = nil
+
 	idxIdent := &ast.Ident{Name: name.Name + "Idx"}
-	_, eltResolver, err := gen.ResolveValue(
-		&ast.Ident{Name: name.Name + "Elt"},
-		&ast.CallExpr{
-			Fun: &ast.SelectorExpr{
-				X:   jsValue,
-				Sel: &ast.Ident{Name: "Index"},
+	var eltResolver []ast.Stmt
+	if starType, ok := nativeType.Elt.(*ast.StarExpr); ok && gen.config.SharePointers {
+		var seenResolver []ast.Stmt
+		seenResolver, eltResolver, err = gen.resolveSharedPointers(name, jsValue, starType, dst, idxIdent)
+		resolver = append(resolver, seenResolver...)
+	} else {
+		_, eltResolver, err = gen.ResolveValue(
+			&ast.Ident{Name: name.Name + "Elt"},
+			&ast.CallExpr{
+				Fun: &ast.SelectorExpr{
+					X:   jsValue,
+					Sel: &ast.Ident{Name: "Index"},
+				},
+				Args: []ast.Expr{idxIdent},
 			},
-			Args: []ast.Expr{idxIdent},
-		},
-		nativeType.Elt,
-		&ast.IndexExpr{X: dst, Index: idxIdent},
-	)
+			nativeType.Elt,
+			&ast.IndexExpr{X: dst, Index: idxIdent},
+		)
+	}
 	if err != nil {
 		return nil, nil, fmt.Errorf("Unresolved array element type %v: %v", nativeType.Elt, err)
 	}
 
-	return dst, append(
-		resolver,
-		&ast.ForStmt{
+	if validator != nil {
+		// elements are validated as they're resolved, throwing at the first invalid one:
+		// 	if err := validate(dst[nameIdx]); err != nil {
+		// 		panic(js.Global().Get("Error").New(fmt.Sprintf("Invalid element %d of name: %v", nameIdx, err)))
+		// 	}
+		gen.imports["fmt"] = true
+		eltResolver = append(eltResolver, &ast.IfStmt{
 			Init: &ast.AssignStmt{
-				Lhs: []ast.Expr{idxIdent},
+				Lhs: []ast.Expr{&ast.Ident{Name: "err"}},
 				Tok: token.DEFINE,
 				Rhs: []ast.Expr{
-					&ast.BasicLit{
-						Kind:  token.INT,
-						Value: "0",
+					&ast.CallExpr{
+						Fun:  validator,
+						Args: []ast.Expr{&ast.IndexExpr{X: dst, Index: idxIdent}},
 					},
 				},
 			},
 			Cond: &ast.BinaryExpr{
-				X:  idxIdent,
-				Op: token.LSS,
-				Y:  lenExpr,
-			},
-			Post: &ast.IncDecStmt{
-				X:   idxIdent,
-				Tok: token.INC,
+				X:  &ast.Ident{Name: "err"},
+				Op: token.NEQ,
+				Y:  &ast.Ident{Name: "nil"},
 			},
 			Body: &ast.BlockStmt{
-				List: eltResolver,
+				List: []ast.Stmt{
+					gen.throwStmt(&ast.CallExpr{
+						Fun: &ast.SelectorExpr{
+							X:   &ast.Ident{Name: "fmt"},
+							Sel: &ast.Ident{Name: "Sprintf"},
+						},
+						Args: []ast.Expr{
+							&ast.BasicLit{Kind: token.STRING, Value: strconv.Quote("Invalid element %d of " + name.Name + ": %v")},
+							idxIdent,
+							&ast.Ident{Name: "err"},
+						},
+					}),
+				},
 			},
-		},
-	), err
-}
+		})
+	}
 
-func (gen *generator) resolveStruct(
-	name *ast.Ident,
-	jsValue ast.Expr,
-	nativeType *ast.StructType,
-	dst ast.Expr,
-) (expr ast.Expr, resolver []ast.Stmt, err error) {
-	if dst == nil {
-		resolver = append(resolver, &ast.AssignStmt{
+	resolver = gen.withResolverCount("array", resolver)
+
+	var loop ast.Stmt = &ast.ForStmt{
+		Init: &ast.AssignStmt{
+			Lhs: []ast.Expr{idxIdent},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.BasicLit{
+					Kind:  token.INT,
+					Value: "0",
+				},
+			},
+		},
+		Cond: &ast.BinaryExpr{
+			X:  idxIdent,
+			Op: token.LSS,
+			Y:  lenExpr,
+		},
+		Post: &ast.IncDecStmt{
+			X:   idxIdent,
+			Tok: token.INC,
+		},
+		Body: &ast.BlockStmt{
+			List: eltResolver,
+		},
+	}
+
+	// validated elements are always resolved one by one
+	if elt, ok := nativeType.Elt.(*ast.Ident); ok && (elt.Name == "byte" || elt.Name == "uint8") && nativeType.Len == nil && validator == nil {
+		// Uint8Arrays are copied in one call rather than element by element:
+		// 	if jsValue.InstanceOf(js.Global().Get("Uint8Array")) {
+		// 		js.CopyBytesToGo(dst, jsValue)
+		// 	} else {
+		// 		...
+		// 	}
+		loop = &ast.IfStmt{
+			Cond: &ast.CallExpr{
+				Fun: &ast.SelectorExpr{
+					X:   jsValue,
+					Sel: &ast.Ident{Name: "InstanceOf"},
+				},
+				Args: []ast.Expr{
+					&ast.CallExpr{
+						Fun: &ast.SelectorExpr{
+							X: &ast.CallExpr{
+								Fun: &ast.SelectorExpr{
+									X:   gen.jsIdent(),
+									Sel: &ast.Ident{Name: "Global"},
+								},
+							},
+							Sel: &ast.Ident{Name: "Get"},
+						},
+						Args: []ast.Expr{&ast.BasicLit{Kind: token.STRING, Value: `"Uint8Array"`}},
+					},
+				},
+			},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					&ast.ExprStmt{
+						X: &ast.CallExpr{
+							Fun: &ast.SelectorExpr{
+								X:   gen.jsIdent(),
+								Sel: &ast.Ident{Name: "CopyBytesToGo"},
+							},
+							Args: []ast.Expr{dst, jsValue},
+						},
+					},
+				},
+			},
+			Else: &ast.BlockStmt{List: []ast.Stmt{loop}},
+		}
+	} else if typedArray, ok := typedArrays[types.ExprString(nativeType.Elt)]; ok && gen.config.UnsafeTypedArrays && nativeType.Len == nil && validator == nil {
+		// matching typed arrays are copied into the slice's memory rather than element by element:
+		// 	if len(dst) > 0 && jsValue.InstanceOf(js.Global().Get("Float32Array")) {
+		// 		wasmCopyTypedArray(unsafe.Pointer(&dst[0]), len(dst)*4, jsValue)
+		// 	} else {
+		// 		...
+		// 	}
+		gen.useHelper("typedarray")
+		gen.imports["unsafe"] = true
+		loop = &ast.IfStmt{
+			Cond: &ast.BinaryExpr{
+				X: &ast.BinaryExpr{
+					X:  &ast.CallExpr{Fun: &ast.Ident{Name: "len"}, Args: []ast.Expr{dst}},
+					Op: token.GTR,
+					Y:  &ast.BasicLit{Kind: token.INT, Value: "0"},
+				},
+				Op: token.LAND,
+				Y: &ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X:   jsValue,
+						Sel: &ast.Ident{Name: "InstanceOf"},
+					},
+					Args: []ast.Expr{
+						&ast.CallExpr{
+							Fun: &ast.SelectorExpr{
+								X: &ast.CallExpr{
+									Fun: &ast.SelectorExpr{
+										X:   gen.jsIdent(),
+										Sel: &ast.Ident{Name: "Global"},
+									},
+								},
+								Sel: &ast.Ident{Name: "Get"},
+							},
+							Args: []ast.Expr{&ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(typedArray.name)}},
+						},
+					},
+				},
+			},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					&ast.ExprStmt{
+						X: &ast.CallExpr{
+							Fun: &ast.Ident{Name: "wasmCopyTypedArray"},
+							Args: []ast.Expr{
+								&ast.CallExpr{
+									Fun: &ast.SelectorExpr{
+										X:   &ast.Ident{Name: "unsafe"},
+										Sel: &ast.Ident{Name: "Pointer"},
+									},
+									Args: []ast.Expr{
+										&ast.UnaryExpr{
+											Op: token.AND,
+											X: &ast.IndexExpr{
+												X:     dst,
+												Index: &ast.BasicLit{Kind: token.INT, Value: "0"},
+											},
+										},
+									},
+								},
+								&ast.BinaryExpr{
+									X:  &ast.CallExpr{Fun: &ast.Ident{Name: "len"}, Args: []ast.Expr{dst}},
+									Op: token.MUL,
+									Y:  &ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(typedArray.size)},
+								},
+								jsValue,
+							},
+						},
+					},
+				},
+			},
+			Else: &ast.BlockStmt{List: []ast.Stmt{loop}},
+		}
+	}
+
+	return dst, append(resolver, loop), err
+}
+
+// the js typed arrays sharing the memory layout of a go slice, keyed by element type
+var typedArrays = map[string]struct {
+	name string
+	size int
+}{
+	"int8":    {"Int8Array", 1},
+	"int16":   {"Int16Array", 2},
+	"int32":   {"Int32Array", 4},
+	"uint16":  {"Uint16Array", 2},
+	"uint32":  {"Uint32Array", 4},
+	"float32": {"Float32Array", 4},
+	"float64": {"Float64Array", 8},
+}
+
+// returns the resolver of the pointer elements of an array with shared pointers,
+// where elements holding the same js object point at the same resolved value,
+// along with the statement declaring the js Map of seen objects
+//
+// generated resolvers:
+// 	nameSeen := js.Global().Get("Map").New()
+// 	...
+// 	nameEltValue := jsValue.Index(nameIdx)
+// 	if nameSeenIdx := nameSeen.Call("get", nameEltValue); !nameSeenIdx.IsUndefined() {
+// 		dst[nameIdx] = dst[nameSeenIdx.Int()]
+// 	} else if !nameEltValue.IsUndefined() && !nameEltValue.IsNull() {
+// 		var nameElt T
+// 		...
+// 		dst[nameIdx] = &nameElt
+// 		if nameEltValue.Type() == js.TypeObject {
+// 			nameSeen.Call("set", nameEltValue, nameIdx)
+// 		}
+// 	}
+func (gen *generator) resolveSharedPointers(
+	name *ast.Ident,
+	jsValue ast.Expr,
+	nativeType *ast.StarExpr,
+	dst ast.Expr,
+	idx *ast.Ident,
+) (seenResolver []ast.Stmt, eltResolver []ast.Stmt, err error) {
+	seen := &ast.Ident{Name: name.Name + "Seen"}
+	seenIdx := &ast.Ident{Name: name.Name + "SeenIdx"}
+	eltValue := &ast.Ident{Name: name.Name + "EltValue"}
+	elt := &ast.Ident{Name: name.Name + "Elt"}
+	dstElt := &ast.IndexExpr{X: dst, Index: idx}
+
+	_, valueResolver, err := gen.ResolveValue(elt, eltValue, nativeType.X, elt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	valueResolver = append([]ast.Stmt{
+		&ast.DeclStmt{
+			Decl: &ast.GenDecl{
+				Tok: token.VAR,
+				Specs: []ast.Spec{
+					&ast.ValueSpec{
+						Names: []*ast.Ident{elt},
+						Type:  nativeType.X,
+					},
+				},
+			},
+		},
+	}, valueResolver...)
+	valueResolver = append(valueResolver,
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{dstElt},
+			Tok: token.ASSIGN,
+			Rhs: []ast.Expr{&ast.UnaryExpr{Op: token.AND, X: elt}},
+		},
+		&ast.IfStmt{
+			Cond: &ast.BinaryExpr{
+				X: &ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X:   eltValue,
+						Sel: &ast.Ident{Name: "Type"},
+					},
+				},
+				Op: token.EQL,
+				Y: &ast.SelectorExpr{
+					X:   gen.jsIdent(),
+					Sel: &ast.Ident{Name: "TypeObject"},
+				},
+			},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					&ast.ExprStmt{
+						X: &ast.CallExpr{
+							Fun: &ast.SelectorExpr{
+								X:   seen,
+								Sel: &ast.Ident{Name: "Call"},
+							},
+							Args: []ast.Expr{
+								&ast.BasicLit{Kind: token.STRING, Value: `"set"`},
+								eltValue,
+								idx,
+							},
+						},
+					},
+				},
+			},
+		},
+	)
+
+	seenResolver = []ast.Stmt{
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{seen},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X: &ast.CallExpr{
+							Fun: &ast.SelectorExpr{
+								X: &ast.CallExpr{
+									Fun: &ast.SelectorExpr{
+										X:   gen.jsIdent(),
+										Sel: &ast.Ident{Name: "Global"},
+									},
+								},
+								Sel: &ast.Ident{Name: "Get"},
+							},
+							Args: []ast.Expr{&ast.BasicLit{Kind: token.STRING, Value: `"Map"`}},
+						},
+						Sel: &ast.Ident{Name: "New"},
+					},
+				},
+			},
+		},
+	}
+
+	eltResolver = []ast.Stmt{
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{eltValue},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X:   jsValue,
+						Sel: &ast.Ident{Name: "Index"},
+					},
+					Args: []ast.Expr{idx},
+				},
+			},
+		},
+		&ast.IfStmt{
+			Init: &ast.AssignStmt{
+				Lhs: []ast.Expr{seenIdx},
+				Tok: token.DEFINE,
+				Rhs: []ast.Expr{
+					&ast.CallExpr{
+						Fun: &ast.SelectorExpr{
+							X:   seen,
+							Sel: &ast.Ident{Name: "Call"},
+						},
+						Args: []ast.Expr{
+							&ast.BasicLit{Kind: token.STRING, Value: `"get"`},
+							eltValue,
+						},
+					},
+				},
+			},
+			Cond: &ast.UnaryExpr{
+				Op: token.NOT,
+				X: &ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X:   seenIdx,
+						Sel: &ast.Ident{Name: "IsUndefined"},
+					},
+				},
+			},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					&ast.AssignStmt{
+						Lhs: []ast.Expr{dstElt},
+						Tok: token.ASSIGN,
+						Rhs: []ast.Expr{
+							&ast.IndexExpr{
+								X: dst,
+								Index: &ast.CallExpr{
+									Fun: &ast.SelectorExpr{
+										X:   seenIdx,
+										Sel: &ast.Ident{Name: "Int"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			Else: &ast.IfStmt{
+				Cond: isPresentExpr(eltValue),
+				Body: &ast.BlockStmt{List: valueResolver},
+			},
+		},
+	}
+
+	return seenResolver, eltResolver, err
+}
+
+// resolves a map from the own enumerable properties of a js object,
+// and those inherited through its prototype chain with MergePrototype.
+// keys are resolved from the property names: strings as is, numbers through js Number(),
+// structs as json and registered types from the name string
+//
+// generated resolver:
+// 	nameKeys := js.Global().Get("Object").Call("keys", jsValue)
+// 	name := make(map[K]V, nameKeys.Length())
+// 	for nameIdx := 0; nameIdx < nameKeys.Length(); nameIdx++ {
+// 		nameKey := nameKeys.Index(nameIdx)
+// 		...
+// 		name[nameKey.String()] = ...
+// 	}
+func (gen *generator) resolveMap(
+	name *ast.Ident,
+	jsValue ast.Expr,
+	nativeType *ast.MapType,
+	dst ast.Expr,
+) (expr ast.Expr, resolver []ast.Stmt, err error) {
+	keys := &ast.Ident{Name: name.Name + "Keys"}
+	idx := &ast.Ident{Name: name.Name + "Idx"}
+	key := &ast.Ident{Name: name.Name + "Key"}
+	keysLen := &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   keys,
+			Sel: &ast.Ident{Name: "Length"},
+		},
+	}
+
+	resolver = append(resolver, &ast.AssignStmt{
+		Lhs: []ast.Expr{keys},
+		Tok: token.DEFINE,
+		Rhs: []ast.Expr{
+			&ast.CallExpr{
+				Fun: &ast.SelectorExpr{
+					X: &ast.CallExpr{
+						Fun: &ast.SelectorExpr{
+							X: &ast.CallExpr{
+								Fun: &ast.SelectorExpr{
+									X:   gen.jsIdent(),
+									Sel: &ast.Ident{Name: "Global"},
+								},
+							},
+							Sel: &ast.Ident{Name: "Get"},
+						},
+						Args: []ast.Expr{&ast.BasicLit{Kind: token.STRING, Value: `"Object"`}},
+					},
+					Sel: &ast.Ident{Name: "Call"},
+				},
+				Args: []ast.Expr{
+					&ast.BasicLit{Kind: token.STRING, Value: `"keys"`},
+					gen.mergedPrototype(jsValue),
+				},
+			},
+		},
+	})
+
+	makeMap := &ast.CallExpr{
+		Fun:  &ast.Ident{Name: "make"},
+		Args: []ast.Expr{nativeType, keysLen},
+	}
+	if dst == nil {
+		resolver = append(resolver, &ast.AssignStmt{
+			Lhs: []ast.Expr{name},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{makeMap},
+		})
+
+		dst = name
+	} else {
+		resolver = append(resolver, &ast.AssignStmt{
+			Lhs: []ast.Expr{dst},
+			Tok: token.ASSIGN,
+			Rhs: []ast.Expr{makeMap},
+		})
+	}
+
+	keyExpr, keyResolver, err := gen.resolveMapKey(name, key, nativeType.Key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	eltExpr, eltResolver, err := gen.ResolveValue(
+		&ast.Ident{Name: name.Name + "Elt"},
+		&ast.CallExpr{
+			Fun: &ast.SelectorExpr{
+				X:   jsValue,
+				Sel: &ast.Ident{Name: "Get"},
+			},
+			Args: []ast.Expr{
+				&ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X:   key,
+						Sel: &ast.Ident{Name: "String"},
+					},
+				},
+			},
+		},
+		nativeType.Value,
+		nil,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Unresolved map value type %v: %v", nativeType.Value, err)
+	}
+
+	body := []ast.Stmt{
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{key},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X:   keys,
+						Sel: &ast.Ident{Name: "Index"},
+					},
+					Args: []ast.Expr{idx},
+				},
+			},
+		},
+	}
+	body = append(body, keyResolver...)
+	body = append(body, eltResolver...)
+	body = append(body, &ast.AssignStmt{
+		Lhs: []ast.Expr{&ast.IndexExpr{X: dst, Index: keyExpr}},
+		Tok: token.ASSIGN,
+		Rhs: []ast.Expr{eltExpr},
+	})
+
+	resolver = gen.withResolverCount("map", resolver)
+
+	return dst, append(resolver, &ast.ForStmt{
+		Init: &ast.AssignStmt{
+			Lhs: []ast.Expr{idx},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{&ast.BasicLit{Kind: token.INT, Value: "0"}},
+		},
+		Cond: &ast.BinaryExpr{X: idx, Op: token.LSS, Y: keysLen},
+		Post: &ast.IncDecStmt{X: idx, Tok: token.INC},
+		Body: &ast.BlockStmt{List: body},
+	}), err
+}
+
+// resolves a map key of the given type from a js property name
+//
+// generated resolver for numeric keys:
+// 	js.Global().Get("Number").Invoke(nameKey).Int()
+//
+// generated resolver for struct keys:
+// 	var nameMapKey K
+// 	if err := json.Unmarshal([]byte(nameKey.String()), &nameMapKey); err != nil {
+// 		panic(js.Global().Get("Error").New(err.Error()))
+// 	}
+func (gen *generator) resolveMapKey(
+	name *ast.Ident,
+	key *ast.Ident,
+	nativeType ast.Expr,
+) (expr ast.Expr, resolver []ast.Stmt, err error) {
+	mapKey := &ast.Ident{Name: name.Name + "MapKey"}
+	if gen.getRegisteredType(nativeType) != nil {
+		return gen.ResolveValue(mapKey, key, nativeType, nil)
+	}
+
+	switch underlying := gen.underlyingType(nativeType).(type) {
+	case *ast.Ident:
+		switch underlying.Name {
+		case "string":
+			return gen.ResolveValue(mapKey, key, nativeType, nil)
+		case "int", "int8", "int16", "int32", "rune", "int64",
+			"uint", "uint8", "byte", "uint16", "uint32", "uint64", "uintptr",
+			"float32", "float64":
+			// property names are always strings
+			number := &ast.CallExpr{
+				Fun: &ast.SelectorExpr{
+					X: &ast.CallExpr{
+						Fun: &ast.SelectorExpr{
+							X: &ast.CallExpr{
+								Fun: &ast.SelectorExpr{
+									X:   gen.jsIdent(),
+									Sel: &ast.Ident{Name: "Global"},
+								},
+							},
+							Sel: &ast.Ident{Name: "Get"},
+						},
+						Args: []ast.Expr{&ast.BasicLit{Kind: token.STRING, Value: `"Number"`}},
+					},
+					Sel: &ast.Ident{Name: "Invoke"},
+				},
+				Args: []ast.Expr{key},
+			}
+
+			return gen.ResolveValue(mapKey, number, nativeType, nil)
+		}
+	case *ast.StructType:
+		// struct keys are property names holding json
+		gen.imports["encoding/json"] = true
+		errIdent := &ast.Ident{Name: "err"}
+		return mapKey, []ast.Stmt{
+			&ast.DeclStmt{
+				Decl: &ast.GenDecl{
+					Tok: token.VAR,
+					Specs: []ast.Spec{
+						&ast.ValueSpec{
+							Names: []*ast.Ident{mapKey},
+							Type:  nativeType,
+						},
+					},
+				},
+			},
+			&ast.IfStmt{
+				Init: &ast.AssignStmt{
+					Lhs: []ast.Expr{errIdent},
+					Tok: token.DEFINE,
+					Rhs: []ast.Expr{
+						&ast.CallExpr{
+							Fun: &ast.SelectorExpr{
+								X:   &ast.Ident{Name: "json"},
+								Sel: &ast.Ident{Name: "Unmarshal"},
+							},
+							Args: []ast.Expr{
+								&ast.CallExpr{
+									Fun: &ast.ArrayType{Elt: &ast.Ident{Name: "byte"}},
+									Args: []ast.Expr{
+										&ast.CallExpr{
+											Fun: &ast.SelectorExpr{
+												X:   key,
+												Sel: &ast.Ident{Name: "String"},
+											},
+										},
+									},
+								},
+								&ast.UnaryExpr{Op: token.AND, X: mapKey},
+							},
+						},
+					},
+				},
+				Cond: &ast.BinaryExpr{X: errIdent, Op: token.NEQ, Y: &ast.Ident{Name: "nil"}},
+				Body: &ast.BlockStmt{
+					List: []ast.Stmt{
+						gen.throwStmt(&ast.CallExpr{
+							Fun: &ast.SelectorExpr{
+								X:   errIdent,
+								Sel: &ast.Ident{Name: "Error"},
+							},
+						}),
+					},
+				},
+			},
+		}, nil
+	}
+
+	return nil, nil, fmt.Errorf("Unsupported map key type %s: keys must round-trip through js property names", types.ExprString(nativeType))
+}
+
+// resolves a []rune from either a js string or an array of code points
+func (gen *generator) resolveRunes(
+	name *ast.Ident,
+	jsValue ast.Expr,
+	nativeType *ast.ArrayType,
+	dst ast.Expr,
+) (expr ast.Expr, resolver []ast.Stmt, err error) {
+	if dst == nil {
+		resolver = append(resolver, &ast.DeclStmt{
+			Decl: &ast.GenDecl{
+				Tok: token.VAR,
+				Specs: []ast.Spec{
+					&ast.ValueSpec{
+						Names: []*ast.Ident{name},
+						Type:  nativeType,
+					},
+				},
+			},
+		})
+
+		dst = name
+	}
+
+	// the code points are resolved element by element into a slice of the js array's length
+	_, arrayResolver, err := gen.resolveArray(name, jsValue, &ast.ArrayType{Elt: &ast.Ident{Name: "int32"}}, dst)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resolver = gen.withResolverCount("runes", resolver)
+
+	arrayResolver = append([]ast.Stmt{
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{dst},
+			Tok: token.ASSIGN,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun: &ast.Ident{Name: "make"},
+					Args: []ast.Expr{
+						nativeType,
+						&ast.CallExpr{
+							Fun: &ast.SelectorExpr{
+								X:   jsValue,
+								Sel: &ast.Ident{Name: "Length"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}, arrayResolver...)
+
+	return dst, append(
+		resolver,
+		&ast.IfStmt{
+			Cond: &ast.BinaryExpr{
+				X: &ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X:   jsValue,
+						Sel: &ast.Ident{Name: "Type"},
+					},
+				},
+				Op: token.EQL,
+				Y: &ast.SelectorExpr{
+					X:   gen.jsIdent(),
+					Sel: &ast.Ident{Name: "TypeString"},
+				},
+			},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					&ast.AssignStmt{
+						Lhs: []ast.Expr{dst},
+						Tok: token.ASSIGN,
+						Rhs: []ast.Expr{
+							&ast.CallExpr{
+								Fun: nativeType,
+								Args: []ast.Expr{
+									&ast.CallExpr{
+										Fun: &ast.SelectorExpr{
+											X:   jsValue,
+											Sel: &ast.Ident{Name: "String"},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			Else: &ast.BlockStmt{List: arrayResolver},
+		},
+	), err
+}
+
+func (gen *generator) resolveStruct(
+	name *ast.Ident,
+	jsValue ast.Expr,
+	nativeType *ast.StructType,
+	dst ast.Expr,
+) (expr ast.Expr, resolver []ast.Stmt, err error) {
+	// fields with a registered resolver are found by the path from the named type,
+	// the structs of nested fields being resolved with their own names
+	structName := gen.structName
+	gen.structName = ""
+	defer func() { gen.structName = structName }()
+
+	if dst == nil {
+		resolver = append(resolver, &ast.AssignStmt{
 			Lhs: []ast.Expr{name},
 			Tok: token.DEFINE,
 			Rhs: []ast.Expr{
 				&ast.CompositeLit{Type: nativeType},
 			},
 		})
-
-		dst = name
+
+		dst = name
+	}
+
+	// presence checks of the fields in each oneof group, in declaration order
+	var oneofGroups []string
+	oneofFields := make(map[string][]ast.Expr)
+	oneofNames := make(map[string][]string)
+
+	// with tuple structs, js arrays are resolved by field position
+	//
+	// generated check:
+	// 	nameIsTuple := js.Global().Get("Array").Call("isArray", jsValue).Bool()
+	isTuple := &ast.Ident{Name: name.Name + "IsTuple"}
+	if gen.config.TupleStructs && nativeType.Fields.NumFields() > 0 {
+		resolver = append(resolver, &ast.AssignStmt{
+			Lhs: []ast.Expr{isTuple},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X: &ast.CallExpr{
+							Fun: &ast.SelectorExpr{
+								X: &ast.CallExpr{
+									Fun: &ast.SelectorExpr{
+										X: &ast.CallExpr{
+											Fun: &ast.SelectorExpr{
+												X:   gen.jsIdent(),
+												Sel: &ast.Ident{Name: "Global"},
+											},
+										},
+										Sel: &ast.Ident{Name: "Get"},
+									},
+									Args: []ast.Expr{
+										&ast.BasicLit{Kind: token.STRING, Value: "\"Array\""},
+									},
+								},
+								Sel: &ast.Ident{Name: "Call"},
+							},
+							Args: []ast.Expr{
+								&ast.BasicLit{Kind: token.STRING, Value: "\"isArray\""},
+								jsValue,
+							},
+						},
+						Sel: &ast.Ident{Name: "Bool"},
+					},
+				},
+			},
+		})
 	}
 
+	var fieldIdx int
+	var fieldResolvers []ast.Stmt
 	for _, field := range nativeType.Fields.List {
+		tag, err := parseFieldTag(field)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(field.Names) == 0 {
+			// fields of embedded structs are promoted, so they're resolved from the same js value
+			embedded, ok := gen.underlyingType(field.Type).(*ast.StructType)
+			if !ok {
+				return nil, nil, fmt.Errorf("Unresolvable embedded field %s", types.ExprString(field.Type))
+			}
+
+			embeddedName := types.ExprString(field.Type)
+			if selector, ok := field.Type.(*ast.SelectorExpr); ok {
+				embeddedName = selector.Sel.Name
+			}
+
+			_, embeddedResolver, err := gen.resolveStruct(
+				&ast.Ident{Name: name.Name + embeddedName},
+				jsValue,
+				embedded,
+				&ast.SelectorExpr{X: dst, Sel: &ast.Ident{Name: embeddedName}},
+			)
+			if err != nil {
+				return nil, nil, fmt.Errorf("Unresolved embedded field %s: %v", embeddedName, err)
+			}
+
+			fieldResolvers = append(fieldResolvers, embeddedResolver...)
+			continue
+		}
+
 		for _, fieldName := range field.Names {
-			_, fieldResolver, err := gen.ResolveValue(
-				&ast.Ident{Name: name.Name + fieldName.Name},
-				&ast.CallExpr{
-					Fun: &ast.SelectorExpr{
-						X:   jsValue,
-						Sel: &ast.Ident{Name: "Get"},
-					},
-					Args: []ast.Expr{fieldName},
-				},
-				field.Type,
-				&ast.SelectorExpr{
-					X:   dst,
-					Sel: fieldName,
+			if _, ok := tag.options["computed"]; ok || tag.name == "-" {
+				// derived and skipped fields are never read from js
+				fieldIdx++
+				continue
+			}
+
+			// method-style calls may pass some fields as properties of this
+			source := jsValue
+			_, fromThis := tag.options["fromThis"]
+			if fromThis {
+				if gen.this == nil {
+					return nil, nil, fmt.Errorf("Field %s can only be resolved from this within a wrapper", fieldName.Name)
+				}
+
+				source = gen.this
+			}
+
+			fieldKey := &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(gen.propertyName(tag, fieldName))}
+			var fieldValue ast.Expr = &ast.CallExpr{
+				Fun: &ast.SelectorExpr{
+					X:   source,
+					Sel: &ast.Ident{Name: "Get"},
 				},
-			)
+				Args: []ast.Expr{fieldKey},
+			}
+
+			if group, ok := tag.options["oneofgroup"]; ok {
+				if _, ok := oneofFields[group]; !ok {
+					oneofGroups = append(oneofGroups, group)
+				}
+
+				oneofFields[group] = append(oneofFields[group], isPresentExpr(fieldValue))
+				oneofNames[group] = append(oneofNames[group], gen.propertyName(tag, fieldName))
+			}
+
+			// composite fields read their js value once, rather than once per access of their nested resolvers,
+			// each Get being a call into js
+			_, isBasic := gen.underlyingType(field.Type).(*ast.Ident)
+			aliases := gen.propertyAliases(tag, fieldName)
+			if gen.config.CallFieldMethods || gen.config.TupleStructs || len(aliases) > 0 || !isBasic {
+				// the field's js value is held in a variable so the fallbacks below can replace it
+				hoistedValue := &ast.Ident{Name: name.Name + fieldName.Name + "Value"}
+				fieldResolvers = append(fieldResolvers, &ast.AssignStmt{
+					Lhs: []ast.Expr{hoistedValue},
+					Tok: token.DEFINE,
+					Rhs: []ast.Expr{fieldValue},
+				})
+
+				for _, alias := range aliases {
+					// absent properties are read from the field's other names in turn:
+					// 	if nameFieldValue.IsUndefined() {
+					// 		nameFieldValue = jsValue.Get("field_alias")
+					// 	}
+					fieldResolvers = append(fieldResolvers, &ast.IfStmt{
+						Cond: &ast.CallExpr{
+							Fun: &ast.SelectorExpr{
+								X:   hoistedValue,
+								Sel: &ast.Ident{Name: "IsUndefined"},
+							},
+						},
+						Body: &ast.BlockStmt{
+							List: []ast.Stmt{
+								&ast.AssignStmt{
+									Lhs: []ast.Expr{hoistedValue},
+									Tok: token.ASSIGN,
+									Rhs: []ast.Expr{
+										&ast.CallExpr{
+											Fun: &ast.SelectorExpr{
+												X:   source,
+												Sel: &ast.Ident{Name: "Get"},
+											},
+											Args: []ast.Expr{
+												&ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(alias)},
+											},
+										},
+									},
+								},
+							},
+						},
+					})
+				}
+
+				if gen.config.CallFieldMethods {
+					// class instances may expose the field as a zero-arg method instead of a property:
+					// 	if nameFieldValue.Type() == js.TypeFunction {
+					// 		nameFieldValue = jsValue.Call("Field")
+					// 	}
+					fieldResolvers = append(fieldResolvers, &ast.IfStmt{
+						Cond: &ast.BinaryExpr{
+							X: &ast.CallExpr{
+								Fun: &ast.SelectorExpr{
+									X:   hoistedValue,
+									Sel: &ast.Ident{Name: "Type"},
+								},
+							},
+							Op: token.EQL,
+							Y: &ast.SelectorExpr{
+								X:   gen.jsIdent(),
+								Sel: &ast.Ident{Name: "TypeFunction"},
+							},
+						},
+						Body: &ast.BlockStmt{
+							List: []ast.Stmt{
+								&ast.AssignStmt{
+									Lhs: []ast.Expr{hoistedValue},
+									Tok: token.ASSIGN,
+									Rhs: []ast.Expr{
+										&ast.CallExpr{
+											Fun: &ast.SelectorExpr{
+												X:   source,
+												Sel: &ast.Ident{Name: "Call"},
+											},
+											Args: []ast.Expr{fieldKey},
+										},
+									},
+								},
+							},
+						},
+					})
+				}
+
+				if gen.config.TupleStructs && !fromThis {
+					// tuples hold the field at its position in the struct:
+					// 	if nameIsTuple {
+					// 		nameFieldValue = jsValue.Index(i)
+					// 	}
+					fieldResolvers = append(fieldResolvers, &ast.IfStmt{
+						Cond: isTuple,
+						Body: &ast.BlockStmt{
+							List: []ast.Stmt{
+								&ast.AssignStmt{
+									Lhs: []ast.Expr{hoistedValue},
+									Tok: token.ASSIGN,
+									Rhs: []ast.Expr{
+										&ast.CallExpr{
+											Fun: &ast.SelectorExpr{
+												X:   jsValue,
+												Sel: &ast.Ident{Name: "Index"},
+											},
+											Args: []ast.Expr{
+												&ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(fieldIdx)},
+											},
+										},
+									},
+								},
+							},
+						},
+					})
+				}
+
+				fieldValue = hoistedValue
+			}
+			fieldIdx++
+
+			if _, ok := tag.options["required"]; ok {
+				// absent required fields throw:
+				// 	if nameFieldValue.IsUndefined() {
+				// 		panic(js.Global().Get("Error").New("Missing required field Field"))
+				// 	}
+				fieldResolvers = append(fieldResolvers, &ast.IfStmt{
+					Cond: &ast.CallExpr{
+						Fun: &ast.SelectorExpr{
+							X:   fieldValue,
+							Sel: &ast.Ident{Name: "IsUndefined"},
+						},
+					},
+					Body: &ast.BlockStmt{
+						List: []ast.Stmt{
+							gen.throwStmt(&ast.BasicLit{
+								Kind:  token.STRING,
+								Value: strconv.Quote("Missing required field " + gen.propertyName(tag, fieldName)),
+							}),
+						},
+					},
+				})
+			}
+
+			var fieldResolver []ast.Stmt
+			fieldDst := &ast.SelectorExpr{X: dst, Sel: fieldName}
+			if regField, ok := gen.config.fields[structName+"."+fieldName.Name]; ok && structName != "" {
+				_, fieldResolver, err = gen.resolveRegistered(
+					&ast.Ident{Name: name.Name + fieldName.Name},
+					fieldValue,
+					field.Type,
+					regField,
+					fieldDst,
+				)
+			} else {
+				_, fieldResolver, err = gen.ResolveValue(
+					&ast.Ident{Name: name.Name + fieldName.Name},
+					fieldValue,
+					field.Type,
+					fieldDst,
+				)
+			}
 			if err != nil {
 				return nil, nil, fmt.Errorf("Unresolved struct field type %v: %v", field.Type, err)
 			}
 
-			resolver = append(resolver, fieldResolver...)
+			if transforms, ok := tag.options["transform"]; ok {
+				transformer, err := gen.fieldTransformer(fieldDst, field.Type, transforms)
+				if err != nil {
+					return nil, nil, fmt.Errorf("Field %s: %v", fieldName.Name, err)
+				}
+
+				fieldResolver = append(fieldResolver, transformer)
+			}
+
+			if lookup, ok := tag.options["enum"]; ok {
+				// numeric values are mapped through the named lookup table, throwing outside its bounds:
+				// 	if nameFieldValue.Type() == js.TypeNumber {
+				// 		nameFieldIndex := nameFieldValue.Int()
+				// 		if nameFieldIndex < 0 || nameFieldIndex >= len(lookup) {
+				// 			panic(js.Global().Get("Error").New(fmt.Sprintf("Enum value %d of field Field is out of range", nameFieldIndex)))
+				// 		}
+				// 		name.Field = lookup[nameFieldIndex]
+				// 	} else {
+				// 		...
+				// 	}
+				gen.imports["fmt"] = true
+				index := &ast.Ident{Name: name.Name + fieldName.Name + "Index"}
+				fieldResolver = []ast.Stmt{
+					&ast.IfStmt{
+						Cond: &ast.BinaryExpr{
+							X: &ast.CallExpr{
+								Fun: &ast.SelectorExpr{
+									X:   fieldValue,
+									Sel: &ast.Ident{Name: "Type"},
+								},
+							},
+							Op: token.EQL,
+							Y: &ast.SelectorExpr{
+								X:   gen.jsIdent(),
+								Sel: &ast.Ident{Name: "TypeNumber"},
+							},
+						},
+						Body: &ast.BlockStmt{
+							List: []ast.Stmt{
+								&ast.AssignStmt{
+									Lhs: []ast.Expr{index},
+									Tok: token.DEFINE,
+									Rhs: []ast.Expr{
+										&ast.CallExpr{
+											Fun: &ast.SelectorExpr{
+												X:   fieldValue,
+												Sel: &ast.Ident{Name: "Int"},
+											},
+										},
+									},
+								},
+								&ast.IfStmt{
+									Cond: &ast.BinaryExpr{
+										X:  &ast.BinaryExpr{X: index, Op: token.LSS, Y: &ast.BasicLit{Kind: token.INT, Value: "0"}},
+										Op: token.LOR,
+										Y: &ast.BinaryExpr{
+											X:  index,
+											Op: token.GEQ,
+											Y: &ast.CallExpr{
+												Fun:  &ast.Ident{Name: "len"},
+												Args: []ast.Expr{&ast.Ident{Name: lookup}},
+											},
+										},
+									},
+									Body: &ast.BlockStmt{
+										List: []ast.Stmt{
+											gen.throwStmt(&ast.CallExpr{
+												Fun: &ast.SelectorExpr{
+													X:   &ast.Ident{Name: "fmt"},
+													Sel: &ast.Ident{Name: "Sprintf"},
+												},
+												Args: []ast.Expr{
+													&ast.BasicLit{
+														Kind:  token.STRING,
+														Value: strconv.Quote("Enum value %d of field " + fieldName.Name + " is out of range"),
+													},
+													index,
+												},
+											}),
+										},
+									},
+								},
+								&ast.AssignStmt{
+									Lhs: []ast.Expr{
+										&ast.SelectorExpr{
+											X:   dst,
+											Sel: fieldName,
+										},
+									},
+									Tok: token.ASSIGN,
+									Rhs: []ast.Expr{
+										&ast.IndexExpr{
+											X:     &ast.Ident{Name: lookup},
+											Index: index,
+										},
+									},
+								},
+							},
+						},
+						Else: &ast.BlockStmt{List: fieldResolver},
+					},
+				}
+			}
+
+			if gen.config.MergeDefaults {
+				// absent fields keep their current value
+				fieldResolver = []ast.Stmt{
+					&ast.IfStmt{
+						Cond: isPresentExpr(fieldValue),
+						Body: &ast.BlockStmt{List: fieldResolver},
+					},
+				}
+			} else if gen.config.SkipUndefinedFields {
+				// undefined fields keep their zero value rather than being resolved from undefined
+				fieldResolver = []ast.Stmt{
+					&ast.IfStmt{
+						Cond: &ast.UnaryExpr{
+							Op: token.NOT,
+							X: &ast.CallExpr{
+								Fun: &ast.SelectorExpr{
+									X:   fieldValue,
+									Sel: &ast.Ident{Name: "IsUndefined"},
+								},
+							},
+						},
+						Body: &ast.BlockStmt{List: fieldResolver},
+					},
+				}
+			}
+
+			fieldResolvers = append(fieldResolvers, fieldResolver...)
+		}
+	}
+
+	resolver = gen.withResolverCount("struct", resolver)
+	for _, group := range oneofGroups {
+		resolver = append(resolver, gen.oneofCheck(
+			&ast.Ident{Name: name.Name + "OneofCount"},
+			oneofFields[group],
+			fmt.Sprintf("At most one of %s may be set", strings.Join(oneofNames[group], ", ")),
+		))
+	}
+
+	return dst, append(resolver, fieldResolvers...), err
+}
+
+// returns the assignment of the resolved string field to itself passed through the |-separated transforms,
+// applied in order (e.g. trim|lower):
+// 	name.Field = strings.ToLower(strings.TrimSpace(name.Field))
+func (gen *generator) fieldTransformer(fieldDst ast.Expr, fieldType ast.Expr, transforms string) (ast.Stmt, error) {
+	if basic, ok := gen.underlyingType(fieldType).(*ast.Ident); !ok || basic.Name != "string" {
+		return nil, fmt.Errorf("Transforms require a string field, got %s", types.ExprString(fieldType))
+	}
+
+	// named string types are transformed as strings and converted back
+	var value ast.Expr = fieldDst
+	named := types.ExprString(fieldType) != "string"
+	if named {
+		value = &ast.CallExpr{Fun: &ast.Ident{Name: "string"}, Args: []ast.Expr{value}}
+	}
+
+	for _, transformName := range strings.Split(transforms, "|") {
+		transform, ok := gen.config.transforms[transformName]
+		if !ok {
+			transform, ok = builtinTransforms[transformName]
+		}
+		if !ok {
+			return nil, fmt.Errorf("Unknown transform \"%s\"", transformName)
+		}
+
+		for _, path := range transform.imports {
+			gen.imports[path] = true
 		}
+
+		value = transform.transform(value)
+	}
+
+	if named {
+		value = &ast.CallExpr{Fun: fieldType, Args: []ast.Expr{value}}
+	}
+
+	return &ast.AssignStmt{
+		Lhs: []ast.Expr{fieldDst},
+		Tok: token.ASSIGN,
+		Rhs: []ast.Expr{value},
+	}, nil
+}
+
+// returns a block that throws when more than one of the given presence checks is true
+//
+// generated check:
+// 	{
+// 		count := 0
+// 		if !jsValue.Get("A").IsUndefined() && !jsValue.Get("A").IsNull() {
+// 			count++
+// 		}
+// 		...
+// 		if count > 1 {
+// 			panic(js.Global().Get("Error").New(msg))
+// 		}
+// 	}
+func (gen *generator) oneofCheck(count *ast.Ident, presenceChecks []ast.Expr, msg string) ast.Stmt {
+	block := &ast.BlockStmt{
+		List: []ast.Stmt{
+			&ast.AssignStmt{
+				Lhs: []ast.Expr{count},
+				Tok: token.DEFINE,
+				Rhs: []ast.Expr{
+					&ast.BasicLit{Kind: token.INT, Value: "0"},
+				},
+			},
+		},
+	}
+
+	for _, isPresent := range presenceChecks {
+		block.List = append(block.List, &ast.IfStmt{
+			Cond: isPresent,
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					&ast.IncDecStmt{X: count, Tok: token.INC},
+				},
+			},
+		})
 	}
 
-	return dst, resolver, err
+	block.List = append(block.List, &ast.IfStmt{
+		Cond: &ast.BinaryExpr{
+			X:  count,
+			Op: token.GTR,
+			Y:  &ast.BasicLit{Kind: token.INT, Value: "1"},
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				gen.throwStmt(&ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(msg)}),
+			},
+		},
+	})
+
+	return block
 }
 
+// resolves the js arguments of a call into the given params, unnamed ones being named by position
 func (gen *generator) resolveFuncArgs(params *ast.FieldList) (args []ast.Expr, resolver []ast.Stmt, err error) {
+	params = namedParams(params)
 	var i int
 	args = make([]ast.Expr, params.NumFields())
 	resolvers := make([]ast.Stmt, 0)
 
+	// the total size of the resolved strings and slices is bounded by //wasm:maxbytes=n:
+	// 	argsSize := 0
+	maxBytes := -1
+	if directive := gen.getDirective("maxbytes"); directive != nil {
+		maxBytes, err = strconv.Atoi(directive.value)
+		if err != nil || maxBytes < 0 {
+			return nil, nil, fmt.Errorf("Invalid //wasm:maxbytes value \"%s\", expected a byte count", directive.value)
+		}
+
+		resolvers = append(resolvers, &ast.AssignStmt{
+			Lhs: []ast.Expr{&ast.Ident{Name: "argsSize"}},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{&ast.BasicLit{Kind: token.INT, Value: "0"}},
+		})
+	}
+
 	for _, param := range params.List {
 		for _, name := range param.Names {
-			args[i], resolver, err = gen.ResolveValue(
-				name,
-				&ast.IndexExpr{
-					X: &ast.Ident{Name: "args"},
-					Index: &ast.BasicLit{
-						Kind:  token.INT,
-						Value: strconv.Itoa(i),
-					},
+			var jsArg ast.Expr = &ast.IndexExpr{
+				X: &ast.Ident{Name: "args"},
+				Index: &ast.BasicLit{
+					Kind:  token.INT,
+					Value: strconv.Itoa(i),
 				},
-				param.Type,
-				nil,
-			)
+			}
+
+			if gen.getParamDirective("options", name.Name) != nil {
+				// the options struct takes the remaining arguments
+				if i != len(args)-1 {
+					return nil, nil, fmt.Errorf("//wasm:options parameter \"%s\" must be the last parameter", name)
+				}
+
+				args[i], resolver, err = gen.resolveOptions(name, i, param.Type)
+				if err != nil {
+					return nil, nil, err
+				}
+
+				resolvers = append(resolvers, resolver...)
+				i++
+				continue
+			}
+
+			if variadic, ok := param.Type.(*ast.Ellipsis); ok {
+				// the variadic parameter takes the remaining arguments
+				args[i], resolver, err = gen.resolveVariadic(name, i, variadic)
+				if err != nil {
+					return nil, nil, fmt.Errorf("Unresolved argument \"%s\" type %s: %v", name, types.ExprString(param.Type), err)
+				}
+
+				resolvers = append(resolvers, resolver...)
+				if maxBytes >= 0 {
+					args[i], resolver = gen.argSizeCheck(name, args[i], &ast.ArrayType{Elt: variadic.Elt}, maxBytes)
+					resolvers = append(resolvers, resolver...)
+				}
+
+				i++
+				continue
+			}
+
+			if gen.config.Async {
+				// promise arguments are resolved from the value they settle to:
+				// 	nameAwaited := wasmAwaitArg(args[i])
+				awaited := &ast.Ident{Name: name.Name + "Awaited"}
+				gen.useHelper("await")
+				resolvers = append(resolvers, &ast.AssignStmt{
+					Lhs: []ast.Expr{awaited},
+					Tok: token.DEFINE,
+					Rhs: []ast.Expr{
+						&ast.CallExpr{
+							Fun:  &ast.Ident{Name: "wasmAwaitArg"},
+							Args: []ast.Expr{jsArg},
+						},
+					},
+				})
+
+				jsArg = awaited
+			}
+
+			if gen.getParamDirective("flatten", name.Name) != nil {
+				// dotted keys are expanded before resolving:
+				// 	nameNested := wasmUnflatten(args[i])
+				nested := &ast.Ident{Name: name.Name + "Nested"}
+				gen.useHelper("flatten")
+				resolvers = append(resolvers, &ast.AssignStmt{
+					Lhs: []ast.Expr{nested},
+					Tok: token.DEFINE,
+					Rhs: []ast.Expr{
+						&ast.CallExpr{
+							Fun:  &ast.Ident{Name: "wasmUnflatten"},
+							Args: []ast.Expr{gen.mergedPrototype(jsArg)},
+						},
+					},
+				})
+
+				jsArg = nested
+			}
+
+			if schema := gen.getParamDirective("schema", name.Name); schema != nil {
+				validation, err := gen.validateSchema(name, jsArg, schema)
+				if err != nil {
+					return nil, nil, err
+				}
+
+				resolvers = append(resolvers, validation)
+			}
+
+			if union := gen.getParamDirective("union", name.Name); union != nil {
+				args[i], resolver, err = gen.resolveUnion(name, jsArg, param.Type, union)
+			} else if jsType := gen.getParamDirective("jstype", name.Name); jsType != nil {
+				args[i], resolver, err = gen.resolveJSType(name, jsArg, param.Type, jsType)
+			} else if bitmask := gen.getParamDirective("bitmask", name.Name); bitmask != nil {
+				args[i], resolver, err = gen.resolveBitmask(name, jsArg, param.Type, bitmask)
+			} else if reshape := gen.getParamDirective("reshape", name.Name); reshape != nil {
+				args[i], resolver, err = gen.resolveReshape(name, jsArg, param.Type, reshape)
+			} else if gen.getParamDirective("indexmap", name.Name) != nil {
+				args[i], resolver, err = gen.resolveIndexMap(name, jsArg, param.Type)
+			} else if subarray := gen.getParamDirective("subarray", name.Name); subarray != nil {
+				args[i], resolver, err = gen.resolveSubarray(name, jsArg, param.Type, subarray, params)
+			} else if gen.getParamDirective("hex", name.Name) != nil {
+				args[i], resolver, err = gen.resolveHex(name, jsArg, param.Type)
+			} else if split := gen.getParamDirective("split", name.Name); split != nil {
+				args[i], resolver, err = gen.resolveSplit(name, jsArg, param.Type, split)
+			} else if gen.getParamDirective("columnar", name.Name) != nil {
+				args[i], resolver, err = gen.resolveColumnar(name, jsArg, param.Type)
+			} else if uniqueBy := gen.getParamDirective("uniqueBy", name.Name); uniqueBy != nil {
+				args[i], resolver, err = gen.resolveUniqueBy(name, jsArg, param.Type, uniqueBy)
+			} else if validate := gen.getParamDirective("validate", name.Name); validate != nil {
+				args[i], resolver, err = gen.resolveValidated(name, jsArg, param.Type, validate)
+			} else if gen.getParamDirective("nullAsNaN", name.Name) != nil {
+				args[i], resolver, err = gen.resolveNullAsNaN(name, jsArg, param.Type)
+			} else if _, ok := gen.underlyingType(param.Type).(*ast.StructType); ok && gen.config.Async {
+				args[i], resolver, err = gen.resolveStreamable(name, jsArg, param.Type)
+			} else {
+				args[i], resolver, err = gen.ResolveValue(name, jsArg, param.Type, nil)
+			}
 			if err != nil {
-				return nil, nil, fmt.Errorf("Unresolved argument \"%s\" type %v: %v", name, param.Type, err)
+				return nil, nil, fmt.Errorf("Unresolved argument \"%s\" type %s: %v", name, types.ExprString(param.Type), err)
 			}
 
 			if resolver != nil {
 				resolvers = append(resolvers, resolver...)
 			}
 
+			if maxBytes >= 0 {
+				args[i], resolver = gen.argSizeCheck(name, args[i], param.Type, maxBytes)
+				resolvers = append(resolvers, resolver...)
+			}
+
 			i++
 		}
 	}
 
+	exclusive, err := gen.exclusiveChecks(params)
+	if err != nil {
+		return nil, nil, err
+	}
+	resolvers = append(exclusive, resolvers...)
+
+	if arity := gen.arityCheck(params); arity != nil {
+		resolvers = append([]ast.Stmt{arity}, resolvers...)
+	}
+
 	return args, resolvers, err
 }
+
+// returns a check for each
+// 	//wasm:exclusive a,b
+// directive, throwing when more than one of the listed parameters is passed a value other than undefined or null.
+// arguments that may be omitted (e.g. options) are only checked when passed
+//
+// generated check:
+// 	{
+// 		exclusiveCount := 0
+// 		if !args[0].IsUndefined() && !args[0].IsNull() {
+// 			exclusiveCount++
+// 		}
+// 		...
+// 	}
+func (gen *generator) exclusiveChecks(params *ast.FieldList) ([]ast.Stmt, error) {
+	var checks []ast.Stmt
+	for _, d := range gen.directives {
+		if d.name != "exclusive" {
+			continue
+		}
+
+		var names []string
+		for _, arg := range d.args {
+			names = append(names, strings.Split(arg, ",")...)
+		}
+
+		if len(names) < 2 {
+			return nil, fmt.Errorf("//wasm:exclusive requires at least two parameters, e.g. //wasm:exclusive a,b")
+		}
+
+		presenceChecks := make([]ast.Expr, 0, len(names))
+		for _, name := range names {
+			i := paramIndex(params, name)
+			if i < 0 {
+				return nil, fmt.Errorf("No //wasm:exclusive parameter \"%s\" found", name)
+			}
+
+			var presenceCheck ast.Expr = isPresentExpr(&ast.IndexExpr{
+				X:     &ast.Ident{Name: "args"},
+				Index: &ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(i)},
+			})
+			if gen.isOptionalParam(params, i) {
+				presenceCheck = &ast.BinaryExpr{
+					X: &ast.BinaryExpr{
+						X:  &ast.CallExpr{Fun: &ast.Ident{Name: "len"}, Args: []ast.Expr{&ast.Ident{Name: "args"}}},
+						Op: token.GTR,
+						Y:  &ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(i)},
+					},
+					Op: token.LAND,
+					Y:  presenceCheck,
+				}
+			}
+
+			presenceChecks = append(presenceChecks, presenceCheck)
+		}
+
+		checks = append(checks, gen.oneofCheck(
+			&ast.Ident{Name: "exclusiveCount"},
+			presenceChecks,
+			fmt.Sprintf("At most one of %s may be passed", strings.Join(names, ", ")),
+		))
+	}
+
+	return checks, nil
+}
+
+// reports whether the ith parameter may be omitted by js callers,
+// being an options or variadic parameter or following one
+func (gen *generator) isOptionalParam(params *ast.FieldList, i int) bool {
+	var idx int
+	for _, param := range params.List {
+		for _, name := range param.Names {
+			if _, ok := param.Type.(*ast.Ellipsis); ok || gen.getParamDirective("options", name.Name) != nil {
+				return i >= idx
+			}
+
+			idx++
+		}
+	}
+
+	return false
+}
+
+// returns a statement handling calls passing fewer js arguments than the function has parameters,
+// an options or variadic parameter and the ones after it being optional.
+// missing arguments are undefined, or throw with StrictArity
+//
+// generated check:
+// 	if len(args) < n {
+// 		args = append(args, make([]js.Value, n-len(args))...)
+// 	}
+func (gen *generator) arityCheck(params *ast.FieldList) ast.Stmt {
+	var arity int
+params:
+	for _, param := range params.List {
+		for _, name := range param.Names {
+			if _, ok := param.Type.(*ast.Ellipsis); ok || gen.getParamDirective("options", name.Name) != nil {
+				break params
+			}
+
+			arity++
+		}
+	}
+
+	if arity == 0 {
+		return nil
+	}
+
+	argsIdent := &ast.Ident{Name: "args"}
+	arityLit := &ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(arity)}
+	argsLen := &ast.CallExpr{Fun: &ast.Ident{Name: "len"}, Args: []ast.Expr{argsIdent}}
+
+	var handler ast.Stmt
+	if gen.config.StrictArity {
+		// 	panic(js.Global().Get("Error").New(fmt.Sprintf("Expected n arguments, got %d", len(args))))
+		gen.imports["fmt"] = true
+		handler = gen.throwStmt(&ast.CallExpr{
+			Fun: &ast.SelectorExpr{
+				X:   &ast.Ident{Name: "fmt"},
+				Sel: &ast.Ident{Name: "Sprintf"},
+			},
+			Args: []ast.Expr{
+				&ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(fmt.Sprintf("Expected %d arguments, got %%d", arity))},
+				argsLen,
+			},
+		})
+	} else {
+		// zero js.Values are undefined
+		handler = &ast.AssignStmt{
+			Lhs: []ast.Expr{argsIdent},
+			Tok: token.ASSIGN,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun: &ast.Ident{Name: "append"},
+					Args: []ast.Expr{
+						argsIdent,
+						&ast.CallExpr{
+							Fun: &ast.Ident{Name: "make"},
+							Args: []ast.Expr{
+								&ast.ArrayType{
+									Elt: &ast.SelectorExpr{
+										X:   gen.jsIdent(),
+										Sel: &ast.Ident{Name: "Value"},
+									},
+								},
+								&ast.BinaryExpr{X: arityLit, Op: token.SUB, Y: argsLen},
+							},
+						},
+					},
+					Ellipsis: 1,
+				},
+			},
+		}
+	}
+
+	return &ast.IfStmt{
+		Cond: &ast.BinaryExpr{X: argsLen, Op: token.LSS, Y: arityLit},
+		Body: &ast.BlockStmt{List: []ast.Stmt{handler}},
+	}
+}
+
+// returns statements adding the size of a resolved string or slice argument to argsSize,
+// throwing once it exceeds maxBytes. other arguments aren't counted.
+// arguments resolved inline are first assigned to name so they're only resolved once
+//
+// generated check:
+// 	name := ...
+// 	argsSize += len(name) * int(unsafe.Sizeof(name[0]))
+// 	if argsSize > maxBytes {
+// 		panic(js.Global().Get("Error").New("Arguments exceed maxBytes bytes"))
+// 	}
+func (gen *generator) argSizeCheck(
+	name *ast.Ident,
+	arg ast.Expr,
+	nativeType ast.Expr,
+	maxBytes int,
+) (ast.Expr, []ast.Stmt) {
+	var check []ast.Stmt
+	if _, ok := arg.(*ast.Ident); !ok {
+		check = append(check, &ast.AssignStmt{
+			Lhs: []ast.Expr{name},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{arg},
+		})
+	}
+
+	var size ast.Expr = &ast.CallExpr{Fun: &ast.Ident{Name: "len"}, Args: []ast.Expr{name}}
+	switch underlying := gen.underlyingType(nativeType).(type) {
+	case *ast.Ident:
+		if underlying.Name != "string" {
+			return arg, nil
+		}
+	case *ast.ArrayType:
+		if underlying.Len != nil {
+			return arg, nil
+		}
+
+		if elt, ok := underlying.Elt.(*ast.Ident); !ok || (elt.Name != "byte" && elt.Name != "uint8") {
+			// elements are counted by their size in memory
+			gen.imports["unsafe"] = true
+			size = &ast.BinaryExpr{
+				X:  size,
+				Op: token.MUL,
+				Y: &ast.CallExpr{
+					Fun: &ast.Ident{Name: "int"},
+					Args: []ast.Expr{
+						&ast.CallExpr{
+							Fun: &ast.SelectorExpr{
+								X:   &ast.Ident{Name: "unsafe"},
+								Sel: &ast.Ident{Name: "Sizeof"},
+							},
+							Args: []ast.Expr{
+								&ast.IndexExpr{
+									X:     name,
+									Index: &ast.BasicLit{Kind: token.INT, Value: "0"},
+								},
+							},
+						},
+					},
+				},
+			}
+		}
+	default:
+		return arg, nil
+	}
+
+	argsSize := &ast.Ident{Name: "argsSize"}
+	return name, append(
+		check,
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{argsSize},
+			Tok: token.ADD_ASSIGN,
+			Rhs: []ast.Expr{size},
+		},
+		&ast.IfStmt{
+			Cond: &ast.BinaryExpr{
+				X:  argsSize,
+				Op: token.GTR,
+				Y:  &ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(maxBytes)},
+			},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					gen.throwStmt(&ast.BasicLit{
+						Kind:  token.STRING,
+						Value: strconv.Quote(fmt.Sprintf("Arguments exceed %d bytes", maxBytes)),
+					}),
+				},
+			},
+		},
+	)
+}
+
+// resolves a struct argument that may also be passed as a ReadableStream of json,
+// which is drained and decoded with encoding/json
+//
+// generated resolver:
+// 	var name T
+// 	if wasmIsStream(jsValue) {
+// 		wasmUnmarshalStream(jsValue, &name)
+// 	} else {
+// 		...
+// 	}
+func (gen *generator) resolveStreamable(
+	name *ast.Ident,
+	jsValue ast.Expr,
+	nativeType ast.Expr,
+) (expr ast.Expr, resolver []ast.Stmt, err error) {
+	_, structResolver, err := gen.ResolveValue(name, jsValue, nativeType, name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	gen.useHelper("stream")
+	return name, []ast.Stmt{
+		&ast.DeclStmt{
+			Decl: &ast.GenDecl{
+				Tok: token.VAR,
+				Specs: []ast.Spec{
+					&ast.ValueSpec{
+						Names: []*ast.Ident{name},
+						Type:  nativeType,
+					},
+				},
+			},
+		},
+		&ast.IfStmt{
+			Cond: &ast.CallExpr{
+				Fun:  &ast.Ident{Name: "wasmIsStream"},
+				Args: []ast.Expr{jsValue},
+			},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					&ast.ExprStmt{
+						X: &ast.CallExpr{
+							Fun: &ast.Ident{Name: "wasmUnmarshalStream"},
+							Args: []ast.Expr{
+								jsValue,
+								&ast.UnaryExpr{Op: token.AND, X: name},
+							},
+						},
+					},
+				},
+			},
+			Else: &ast.BlockStmt{List: structResolver},
+		},
+	}, err
+}
@@ -0,0 +1,1692 @@
+package generator
+
+import (
+	"go/ast"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBytesBuffer(t *testing.T) {
+	src := `package main
+
+import "bytes"
+
+func Read(buf *bytes.Buffer) string {
+	return buf.String()
+}
+`
+	got := runWasm(t, src, nil, `Read("text") + "," + Read(new Uint8Array([98, 121, 116, 101, 115]))`)
+	if got != "text,bytes" {
+		t.Errorf("Expected text,bytes, got %s", got)
+	}
+}
+
+func TestOneofGroup(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+type Payment struct {
+	Card *string ` + "`js:\",oneofgroup=method\"`" + `
+	Cash *int    ` + "`js:\",oneofgroup=method\"`" + `
+	Note string
+}
+
+func Pay(p Payment) string {
+	if p.Card != nil {
+		return "card " + *p.Card
+	}
+
+	return fmt.Sprint("cash ", *p.Cash)
+}
+`
+	// a single field of the group may be set, the others not counting
+	got := runWasm(t, src, nil, `[Pay({Card: "4242", Note: "n"}), Pay({Cash: 5})].join(",")`)
+	if want := "card 4242,cash 5"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	if got := runWasmThrows(t, src, nil, `Pay({Card: "4242", Cash: 5})`); got != "At most one of Card, Cash may be set" {
+		t.Errorf("Expected a oneof group error, got %s", got)
+	}
+}
+
+func TestEnumLookup(t *testing.T) {
+	src := `package main
+
+var colors = []string{"red", "green", "blue"}
+
+type Pixel struct {
+	Color string ` + "`js:\",enum=colors\"`" + `
+}
+
+func ColorOf(p Pixel) string {
+	return p.Color
+}
+`
+	// numbers index the lookup within its bounds, other values resolve as strings
+	got := runWasm(t, src, nil, `[ColorOf({Color: 1}), ColorOf({Color: "teal"})].join(",")`)
+	if want := "green,teal"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	if got := runWasmThrows(t, src, nil, `ColorOf({Color: 3})`); got != "Enum value 3 of field Color is out of range" {
+		t.Errorf("Expected an out of range error, got %s", got)
+	}
+}
+
+func TestHandleTypes(t *testing.T) {
+	src := `package main
+
+type File struct {
+	Name string
+}
+
+var files = map[int]any{3: &File{Name: "notes.txt"}, 4: "not a file"}
+
+func init() {
+	RegisterHandleTypeWasm("*File", func(handle int) any {
+		return files[handle]
+	})
+}
+
+func FileName(file *File) string {
+	return file.Name
+}
+`
+	config := NewConfig()
+	config.HandleTypes = []string{"*File"}
+	if got := runWasm(t, src, config, `FileName(3)`); got != "notes.txt" {
+		t.Errorf("Expected notes.txt, got %s", got)
+	}
+
+	// unknown handles and values of other types throw
+	for _, script := range []string{`FileName(4)`, `FileName(5)`} {
+		if got := runWasmThrows(t, src, config, script); got != "Handle is not a *File" {
+			t.Errorf("Expected %s to throw Handle is not a *File, got %s", script, got)
+		}
+	}
+}
+
+func TestRunes(t *testing.T) {
+	src := `package main
+
+func Word(rs []rune) string {
+	return string(rs)
+}
+
+func Count(rs []rune) int {
+	return len(rs)
+}
+`
+	got := runWasm(t, src, nil, `[Word([65, 66, 67]), Word("déjà"), Count("déjà"), Count([0x1F600])].join(",")`)
+	if want := "ABC,déjà,4,1"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestCallFieldMethods(t *testing.T) {
+	src := `package main
+
+type Point struct {
+	X int
+}
+
+func GetX(p Point) int {
+	return p.X
+}
+`
+	config := NewConfig()
+	config.CallFieldMethods = true
+	// a field holding a function is replaced by the result of calling it as a method
+	got := runWasm(t, src, config, `[GetX({X: 1}), GetX({base: 2, X() { return this.base * 3; }})].join(",")`)
+	if want := "1,6"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	if out := generate(t, src, nil); strings.Contains(out, ".Call(") {
+		t.Errorf("Expected no field methods to be called by default:\n%s", out)
+	}
+}
+
+func TestRegisterType(t *testing.T) {
+	src := `package main
+
+import "wasmtest/decimal"
+
+func Double(d decimal.Decimal) string {
+	return d.Add(d).String()
+}
+`
+	config := NewConfig()
+	// 	name := decimal.RequireFromString(jsValue.String())
+	config.RegisterType("decimal.Decimal", func(name *ast.Ident, jsValue ast.Expr) (ast.Expr, []ast.Stmt, error) {
+		return name, []ast.Stmt{
+			&ast.AssignStmt{
+				Lhs: []ast.Expr{name},
+				Tok: token.DEFINE,
+				Rhs: []ast.Expr{
+					&ast.CallExpr{
+						Fun: &ast.SelectorExpr{X: &ast.Ident{Name: "decimal"}, Sel: &ast.Ident{Name: "RequireFromString"}},
+						Args: []ast.Expr{
+							&ast.CallExpr{Fun: &ast.SelectorExpr{X: jsValue, Sel: &ast.Ident{Name: "String"}}},
+						},
+					},
+				},
+			},
+		}, nil
+	})
+	if out := generate(t, src, config); !strings.Contains(out, `"wasmtest/decimal"`) {
+		t.Errorf("Expected the wrapper file to import wasmtest/decimal:\n%s", out)
+	}
+
+	mod := newWasmModule(t, src, config, `Double("1.25")`, map[string]string{
+		"decimal/decimal.go": `package decimal
+
+import (
+	"fmt"
+	"strings"
+)
+
+// a fixed point number with two decimal places
+type Decimal struct {
+	cents int
+}
+
+func RequireFromString(s string) Decimal {
+	units, cents, _ := strings.Cut(s, ".")
+	d := Decimal{}
+	for _, c := range units + (cents + "00")[:2] {
+		d.cents = d.cents*10 + int(c-'0')
+	}
+	return d
+}
+
+func (d Decimal) Add(other Decimal) Decimal {
+	return Decimal{d.cents + other.cents}
+}
+
+func (d Decimal) String() string {
+	return fmt.Sprintf("%d.%02d", d.cents/100, d.cents%100)
+}
+`,
+	})
+	mod.vet(t)
+	got, err := mod.run(t)
+	if err != nil {
+		t.Fatalf("Error running wasm: %v\n%s", err, got)
+	}
+	if got != "2.50" {
+		t.Errorf("Expected 2.50, got %s", got)
+	}
+}
+
+func TestTupleStructs(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+type Person struct {
+	Name   string
+	Age    int
+	Active bool
+}
+
+func Describe(p Person) string {
+	return fmt.Sprint(p.Name, " ", p.Age, " ", p.Active)
+}
+`
+	config := NewConfig()
+	config.TupleStructs = true
+	// each field is read from its own index with its own type, objects are still read by name
+	got := runWasm(t, src, config, `[Describe(["ann", 31, true]), Describe({Name: "bo", Age: 4, Active: false})].join(",")`)
+	if want := "ann 31 true,bo 4 false"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestMergeDefaults(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+type Options struct {
+	Name  string
+	Count int
+}
+
+func defaultOptions() Options {
+	return Options{Name: "default", Count: 1}
+}
+
+func Describe(opts Options) string {
+	return fmt.Sprint(opts.Name, " ", opts.Count)
+}
+`
+	config := NewConfig()
+	config.MergeDefaults = true
+	// the struct starts from its defaults and each field is only assigned when present
+	got := runWasm(t, src, config, `[Describe({}), Describe({Count: 3}), Describe({Name: "custom", Count: 0})].join(",")`)
+	if want := "default 1,default 3,custom 0"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestSkipUndefinedFields(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+type Order struct {
+	ID    int ` + "`wasm:\"id,required\"`" + `
+	Count int
+	Rush  bool
+}
+
+func Place(o Order) string {
+	return fmt.Sprint(o.ID, " ", o.Count, " ", o.Rush)
+}
+`
+	config := NewConfig()
+	config.SkipUndefinedFields = true
+	// absent optional fields keep their zero value instead of being resolved from undefined
+	got := runWasm(t, src, config, `[Place({id: 7}), Place({id: 8, Count: 2, Rush: true})].join(",")`)
+	if want := "7 0 false,8 2 true"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	if got := runWasmThrows(t, src, config, `Place({Count: 2})`); got != "Missing required field id" {
+		t.Errorf("Expected Missing required field id, got %s", got)
+	}
+}
+
+func TestRawMessage(t *testing.T) {
+	src := `package main
+
+import "encoding/json"
+
+func Raw(msg json.RawMessage) string {
+	return string(msg)
+}
+`
+	got := runWasm(t, src, nil, `Raw({a: [1, "b"], c: null}) + " " + Raw("s")`)
+	if want := `{"a":[1,"b"],"c":null} "s"`; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestRequiredFields(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+type Order struct {
+	ID   int    ` + "`js:\",required\"`" + `
+	Note string
+}
+
+func Place(o Order) string {
+	return fmt.Sprint(o.ID, o.Note)
+}
+`
+	// only the required field is checked for presence
+	if got := runWasm(t, src, nil, `Place({ID: 7, Note: ""})`); got != "7" {
+		t.Errorf("Expected 7, got %s", got)
+	}
+
+	if got := runWasmThrows(t, src, nil, `Place({Note: "rush"})`); got != "Missing required field ID" {
+		t.Errorf("Expected Missing required field ID, got %s", got)
+	}
+}
+
+func TestTimeEnums(t *testing.T) {
+	src := `package main
+
+import "time"
+
+func Month(m time.Month) string {
+	return m.String()
+}
+
+func Weekday(d time.Weekday) int {
+	return int(d)
+}
+`
+	got := runWasm(t, src, nil, `[Month(1), Month("January"), Month("march"), Weekday("Saturday"), Weekday(2)].join(",")`)
+	if got != "January,January,March,6,2" {
+		t.Errorf("Expected January,January,March,6,2, got %s", got)
+	}
+
+	if got := runWasmThrows(t, src, nil, `Month("Smarch")`); got != "Unknown month Smarch" {
+		t.Errorf("Expected Unknown month Smarch, got %s", got)
+	}
+}
+
+func TestComplexNumbers(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+func Format(c complex128) string {
+	return fmt.Sprintf("%.2f", c)
+}
+`
+	config := NewConfig()
+	config.PolarComplex = true
+	got := runWasm(t, src, config, `Format({re: 1, im: 2}) + " " + Format({r: 2, theta: Math.PI / 2})`)
+	if got != "(1.00+2.00i) (0.00+2.00i)" {
+		t.Errorf("Expected (1.00+2.00i) (0.00+2.00i), got %s", got)
+	}
+
+	if out := generate(t, src, nil); strings.Contains(out, "cmplx") {
+		t.Errorf("Expected no polar form by default:\n%s", out)
+	}
+}
+
+func TestComplexRoundTrip(t *testing.T) {
+	src := `package main
+
+func Scale(c complex128) complex128 {
+	return c * 2
+}
+
+func Conj(c complex64) complex64 {
+	return complex(real(c), -imag(c))
+}
+`
+	script := `const show = (c) => JSON.stringify(c, Object.keys(c).sort());
+[show(Scale(Scale({re: 1, im: 2}))), show(Conj(Conj({real: 0.5, imag: 3}))), show(Scale({real: 1, imag: -1}))].join(" ")`
+	tests := []struct {
+		name     string
+		realImag bool
+		want     string
+	}{
+		{"re im", false, `{"im":8,"re":4} {"im":3,"re":0.5} {"im":-2,"re":2}`},
+		{"real imag", true, `{"imag":8,"real":4} {"imag":3,"real":0.5} {"imag":-2,"real":2}`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := NewConfig()
+			config.RealImagComplex = test.realImag
+			got := runWasm(t, src, config, script)
+			if got != test.want {
+				t.Errorf("Expected %s, got %s", test.want, got)
+			}
+		})
+	}
+}
+
+func TestBigRat(t *testing.T) {
+	src := `package main
+
+import "math/big"
+
+func Half(r *big.Rat) string {
+	return new(big.Rat).Quo(r, big.NewRat(2, 1)).String()
+}
+`
+	if got := runWasm(t, src, nil, `Half("3/4") + " " + Half("0.5")`); got != "3/8 1/4" {
+		t.Errorf("Expected 3/8 1/4, got %s", got)
+	}
+
+	if got := runWasmThrows(t, src, nil, `Half("3/x")`); got != "Invalid rational 3/x" {
+		t.Errorf("Expected Invalid rational 3/x, got %s", got)
+	}
+}
+
+func TestMaxDepth(t *testing.T) {
+	src := `package main
+
+func Count(grid [][][]int) int {
+	return len(grid)
+}
+`
+	config := NewConfig()
+	config.MaxDepth = 2
+	if got := runWasm(t, src, config, `String(Count([[], []]))`); got != "2" {
+		t.Errorf("Expected 2, got %s", got)
+	}
+
+	if got := runWasmThrows(t, src, config, `Count([[[1]]])`); got != "Maximum resolution depth of 2 exceeded" {
+		t.Errorf("Expected Maximum resolution depth of 2 exceeded, got %s", got)
+	}
+}
+
+func TestIPNet(t *testing.T) {
+	src := `package main
+
+import "net"
+
+func Network(n *net.IPNet) string {
+	return n.String()
+}
+`
+	if got := runWasm(t, src, nil, `Network("10.1.2.3/8")`); got != "10.0.0.0/8" {
+		t.Errorf("Expected 10.0.0.0/8, got %s", got)
+	}
+
+	if got := runWasmThrows(t, src, nil, `Network("10.0.0.0")`); got != "invalid CIDR address: 10.0.0.0" {
+		t.Errorf("Expected invalid CIDR address: 10.0.0.0, got %s", got)
+	}
+}
+
+func TestRegisteredElements(t *testing.T) {
+	src := `package main
+
+import (
+	"fmt"
+	"time"
+)
+
+func Months(months []time.Month) string {
+	return fmt.Sprint(months)
+}
+`
+	// elements of registered types are resolved by their registered resolver
+	if got := runWasm(t, src, nil, `Months([2, "march"])`); got != "[February March]" {
+		t.Errorf("Expected [February March], got %s", got)
+	}
+}
+
+func TestEmbeddedFields(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+type Base struct {
+	ID   int
+	Name string
+}
+
+type Item struct {
+	Base
+	Count int
+}
+
+func Describe(item Item) string {
+	return fmt.Sprintln(item.ID, item.Name, item.Count)
+}
+`
+	// the fields of the embedded struct are read from the same js object
+	got := runWasm(t, src, nil, `Describe({ID: 7, Name: "bolt", Count: 3})`)
+	if want := "7 bolt 3"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestSharePointers(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+type Node struct {
+	Name string
+}
+
+func Shared(nodes []*Node) string {
+	return fmt.Sprintln(nodes[0] == nodes[1], nodes[0] == nodes[2], nodes[2].Name, nodes[3] == nil)
+}
+`
+	config := NewConfig()
+	config.SharePointers = true
+	got := runWasm(t, src, config, `const a = {Name: "a"}; Shared([a, a, {Name: "a"}, null])`)
+	if want := `true false a true`; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestStringifyValues(t *testing.T) {
+	src := `package main
+
+func Echo(s string) string {
+	return s
+}
+`
+	config := NewConfig()
+	config.StringifyValues = true
+	got := runWasm(t, src, config, `[Echo(42), Echo(true), Echo(null), Echo("s")].join(",")`)
+	if got != "42,true,null,s" {
+		t.Errorf("Expected 42,true,null,s, got %s", got)
+	}
+
+	// without the option, js String() isn't used
+	if got := runWasm(t, src, nil, `Echo(42)`); got != "<number: 42>" {
+		t.Errorf("Expected <number: 42>, got %s", got)
+	}
+}
+
+func TestMissingMethodFallbacks(t *testing.T) {
+	src := `package main
+
+import "bytes"
+
+func Read(buf *bytes.Buffer) string {
+	return buf.String()
+}
+`
+	// array-likes other than Uint8Arrays are copied into one
+	got := runWasm(t, src, nil, `Read(new Uint8Array([97]).buffer) + Read([98, 99])`)
+	if got != "abc" {
+		t.Errorf("Expected abc, got %s", got)
+	}
+
+	schemaPath := filepath.Join(t.TempDir(), "level.json")
+	if err := os.WriteFile(schemaPath, []byte(`{"type": "integer", "enum": [1, 2]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	src = `package main
+
+//wasm:schema=` + schemaPath + ` level
+func Level(level int) int {
+	return level
+}
+`
+	// schemas are checked without the methods older runtimes lack
+	got = runWasmThrows(t, src, nil, `delete Number.isInteger; delete Array.prototype.includes; Level(3)`)
+	if want := "Schema validation failed: level must be one of [1,2]"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestComputedFields(t *testing.T) {
+	src := `package main
+
+type Rect struct {
+	W, H int
+	Area int ` + "`js:\",computed\"`" + `
+}
+
+func Measure(r Rect) Rect {
+	return r
+}
+`
+	// computed fields are never read from js, though they're still returned
+	got := runWasm(t, src, nil, `const r = Measure({W: 2, H: 3, Area: 100}); [r.W, r.H, r.Area].join(",")`)
+	if want := "2,3,0"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestAbortSignals(t *testing.T) {
+	src := `package main
+
+func Aborted(done chan struct{}) bool {
+	select {
+	case <-done:
+		return true
+	default:
+		return false
+	}
+}
+
+func Wait(done <-chan struct{}) string {
+	<-done
+	return "aborted"
+}
+`
+	got := runWasm(t, src, nil, `[Aborted(AbortSignal.abort()), Aborted(new AbortController().signal), Aborted(undefined)].join(",")`)
+	if got != "true,false,false" {
+		t.Errorf("Expected true,false,false, got %s", got)
+	}
+
+	// async wrappers can block until the signal aborts
+	config := NewConfig()
+	config.Async = true
+	got = runWasm(t, src, config, `(async () => {
+	const controller = new AbortController();
+	const waiting = Wait(controller.signal);
+	setTimeout(() => controller.abort(), 10);
+	return await waiting;
+})()`)
+	if got != "aborted" {
+		t.Errorf("Expected aborted, got %s", got)
+	}
+}
+
+func TestMaps(t *testing.T) {
+	src := `package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+type Point struct {
+	X, Y int
+}
+
+func Counts(counts map[string]int) string {
+	return fmt.Sprint(counts)
+}
+
+func Groups(groups map[string][]string) string {
+	return fmt.Sprint(groups)
+}
+
+func Squares(squares map[int]int) string {
+	return fmt.Sprint(squares)
+}
+
+func Points(points map[Point]string) string {
+	var names []string
+	for p, name := range points {
+		names = append(names, fmt.Sprintf("%s%d%d", name, p.X, p.Y))
+	}
+	sort.Strings(names)
+	return fmt.Sprint(names)
+}
+`
+	script := `[
+	Counts({a: 1, b: 2}),
+	Groups({x: ["a", "b"], y: []}),
+	Squares({2: 4, 3: 9}),
+	Points({'{"X": 1, "Y": 2}': "p", '{"X": 0, "Y": 0}': "o"}),
+].join(",")`
+	got := runWasm(t, src, nil, script)
+	if want := "map[a:1 b:2],map[x:[a b] y:[]],map[2:4 3:9],[o00 p12]"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	// struct values
+	got = runWasm(t, `package main
+
+type Stock struct {
+	Count int
+}
+
+func Total(stock map[string]Stock) int {
+	total := 0
+	for _, s := range stock {
+		total += s.Count
+	}
+	return total
+}
+`, nil, `String(Total({bolts: {Count: 3}, nuts: {Count: 4}}))`)
+	if got != "7" {
+		t.Errorf("Expected struct values to be resolved from each property, got %s", got)
+	}
+
+	_, err := tryGenerate(`package main
+
+func Flags(flags map[bool]int) {}
+`, nil)
+	if err == nil || !strings.Contains(err.Error(), "Unsupported map key type bool") {
+		t.Errorf("Expected an unsupported key error, got %v", err)
+	}
+}
+
+func TestValidateMethod(t *testing.T) {
+	src := `package main
+
+import "errors"
+
+type Range struct {
+	Min, Max int
+}
+
+func (r *Range) Validate() error {
+	if r.Min > r.Max {
+		return errors.New("min exceeds max")
+	}
+	return nil
+}
+
+func Span(r Range) int {
+	return r.Max - r.Min
+}
+`
+	// the struct is validated once resolved
+	if got := runWasm(t, src, nil, `String(Span({Min: 1, Max: 4}))`); got != "3" {
+		t.Errorf("Expected 3, got %s", got)
+	}
+
+	if got := runWasmThrows(t, src, nil, `Span({Min: 4, Max: 1})`); got != "min exceeds max" {
+		t.Errorf("Expected min exceeds max, got %s", got)
+	}
+
+	if out := generate(t, "package main\n\ntype Range struct {\n\tMin int\n}\n\nfunc Span(r Range) int {\n\treturn r.Min\n}\n", nil); strings.Contains(out, "Validate") {
+		t.Errorf("Expected no Validate call without the method:\n%s", out)
+	}
+}
+
+func TestPointerParams(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+func Describe(n *int) string {
+	if n == nil {
+		return "nil"
+	}
+
+	return fmt.Sprint(*n)
+}
+`
+	// undefined and null leave the pointer nil, other values are resolved into its element
+	got := runWasm(t, src, nil, `[Describe(undefined), Describe(null), Describe(42)].join(",")`)
+	if want := "nil,nil,42"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestFromThisFields(t *testing.T) {
+	src := `package main
+
+type Request struct {
+	Path  string
+	Owner string ` + "`js:\",fromThis\"`" + `
+}
+
+func Handle(r Request) string {
+	return r.Owner + r.Path
+}
+`
+	// fromThis fields are read from the this value of the call rather than the argument
+	got := runWasm(t, src, nil, `Handle.call({Owner: "ann", Path: "/x"}, {Owner: "bo", Path: "/home"})`)
+	if want := "ann/home"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestStructFieldNames(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+type Person struct {
+	Name string
+	Age  int
+}
+
+func Describe(p Person) string {
+	return fmt.Sprint(p.Name, p.Age)
+}
+`
+	out := generate(t, src, nil)
+	for _, get := range []string{`.Get("Name")`, `.Get("Age")`} {
+		if !strings.Contains(out, get) {
+			t.Errorf("Expected generated code to read %s:\n%s", get, out)
+		}
+	}
+
+	got := runWasm(t, src, nil, `Describe({Name: "ann", Age: 31})`)
+	if got != "ann31" {
+		t.Errorf("Expected ann31, got %s", got)
+	}
+}
+
+func TestWasmTagNames(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+type User struct {
+	UserID   int    ` + "`wasm:\"userId\"`" + `
+	Password string ` + "`wasm:\"-\"`" + `
+	Email    string ` + "`js:\"email\"`" + `
+	Name     string
+}
+
+func Describe(u User) string {
+	return fmt.Sprintf("%d %t %s %s", u.UserID, u.Password == "", u.Email, u.Name)
+}
+
+func Echo(u User) User {
+	u.Password = "secret"
+	return u
+}
+`
+	// renamed fields are read from their tagged property, skipped fields never,
+	// and untagged fields from their go name
+	script := `const u = {userId: 7, UserID: 1, Password: "x", email: "a@b", Name: "ann"};
+[Describe(u), JSON.stringify(Echo(u), ["userId", "email", "Name", "Password", "UserID"])].join(",")`
+	got := runWasm(t, src, nil, script)
+	if want := `7 true a@b ann,{"userId":7,"email":"a@b","Name":"ann"}`; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestRegisterPrototypes(t *testing.T) {
+	src := `package main
+
+type Shape interface {
+	Area() float64
+}
+
+type Circle struct {
+	R float64
+}
+
+func (c Circle) Area() float64 {
+	return 3 * c.R * c.R
+}
+
+type Square struct {
+	Side float64
+}
+
+func (s *Square) Area() float64 {
+	return s.Side * s.Side
+}
+
+func Area(s Shape) float64 {
+	return s.Area()
+}
+`
+	config := NewConfig()
+	config.RegisterPrototypes("Shape", map[string]string{"$Circle": "Circle", "Square": "*Square"})
+	// each class resolves into the go type registered for its name
+	script := `class $Circle { constructor(r) { this.R = r; } }
+class Square { constructor(side) { this.Side = side; } }
+[Area(new $Circle(1)), Area(new Square(2))].join(",")`
+	if got := runWasm(t, src, config, script); got != "3,4" {
+		t.Errorf("Expected 3,4, got %s", got)
+	}
+
+	if got := runWasmThrows(t, src, config, `Area({Side: 2})`); got != "Unknown class for s" {
+		t.Errorf("Expected Unknown class for s, got %s", got)
+	}
+}
+
+func TestTimeSelectors(t *testing.T) {
+	src := `package main
+
+import "time"
+
+func Later(at time.Time, by time.Duration) time.Time {
+	return at.Add(by)
+}
+
+func Minutes(d time.Duration) float64 {
+	return d.Minutes()
+}
+`
+	// times are millisecond timestamps, durations nanoseconds or duration strings
+	got := runWasm(t, src, nil, `[Later(1000, 2e6), Minutes("1h30m"), Minutes(6e10)].join(",")`)
+	if want := "1002,90,1"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	_, err := tryGenerate(`package main
+
+import "net/url"
+
+func Host(u url.URL) string {
+	return u.Host
+}
+`, nil)
+	if err == nil || !strings.Contains(err.Error(), "url.URL") {
+		t.Errorf("Expected an error naming url.URL, got %v", err)
+	}
+}
+
+func TestRawMessageMaps(t *testing.T) {
+	src := `package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+func Fields(fields map[string]json.RawMessage) string {
+	keys := make([]string, 0, len(fields))
+	for key, value := range fields {
+		keys = append(keys, key+"="+string(value))
+	}
+	sort.Strings(keys)
+	return fmt.Sprint(keys)
+}
+`
+	// each value is kept as its own JSON, values JSON can't encode as null
+	got := runWasm(t, src, nil, `Fields({n: 1, s: "x", o: {a: [1, true]}, u: undefined})`)
+	if want := `[n=1 o={"a":[1,true]} s="x" u=null]`; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestBytesParams(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+func Sum(data []byte) string {
+	sum := 0
+	for _, b := range data {
+		sum += int(b)
+	}
+	return fmt.Sprint(len(data), " ", sum)
+}
+`
+	// Uint8Arrays are copied with js.CopyBytesToGo, plain arrays still resolved by element
+	script := `const buf = new Uint8Array(1024).map((_, i) => i % 256);
+[Sum(buf), Sum(Array.from(buf)), Sum(new Uint8Array(0))].join(",")`
+	got := runWasm(t, src, nil, script)
+	if want := "1024 130560,1024 130560,0 0"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+// compares resolving a 1MiB []byte from a Uint8Array, copied with js.CopyBytesToGo,
+// to resolving it from a plain array one element at a time
+func BenchmarkBytesParams(b *testing.B) {
+	src := `package main
+
+func Len(data []byte) int {
+	return len(data)
+}
+`
+	benchWasm(b, src, nil, map[string]string{
+		"Uint8Array": `((data) => () => Len(data))(new Uint8Array(1 << 20))`,
+		"Array":      `((data) => () => Len(data))(Array.from(new Uint8Array(1 << 20)))`,
+	})
+}
+
+// counts the property reads of resolving a struct with slice, map and struct fields,
+// each of which is read from js once however many times its resolver accesses it
+func BenchmarkStructFieldReads(b *testing.B) {
+	src := `package main
+
+type Point struct {
+	X, Y float64
+}
+
+type Shape struct {
+	Name   string
+	Points []Point
+	Tags   map[string]string
+	Center Point
+}
+
+func Count(s Shape) int {
+	return len(s.Points) + len(s.Tags)
+}
+`
+	// a proxy counting the reads of the shape's own properties
+	shape := `new Proxy({
+	Name: "triangle",
+	Points: [{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: 1}],
+	Tags: {color: "red"},
+	Center: {X: 0.3, Y: 0.3},
+}, {get: (target, key) => {
+	benchCounts["gets/op"] = (benchCounts["gets/op"] || 0) + 1;
+	return target[key];
+}})`
+	benchWasm(b, src, nil, map[string]string{
+		"Shape": `((shape) => () => Count(shape))(` + shape + `)`,
+	})
+}
+
+func TestUnsafeTypedArrays(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+func Floats(fs []float32) string {
+	return fmt.Sprint(fs)
+}
+
+func Ints(is []int32) string {
+	return fmt.Sprint(is)
+}
+`
+	// typed arrays, views into part of a buffer, empty and plain arrays
+	script := `const ints = new Int32Array([7, -1, 2, 3]);
+[
+	Floats(new Float32Array([1.5, -2, 0.25])),
+	Ints(ints),
+	Ints(new Int32Array(ints.buffer, 4, 2)),
+	Ints(new Int32Array(0)),
+	Floats([1, 2]),
+].join(",")`
+	want := "[1.5 -2 0.25],[7 -1 2 3],[-1 2],[],[1 2]"
+	for _, unsafe := range []bool{false, true} {
+		config := NewConfig()
+		config.UnsafeTypedArrays = unsafe
+		if got := runWasm(t, src, config, script); got != want {
+			t.Errorf("Expected %s with UnsafeTypedArrays %t, got %s", want, unsafe, got)
+		}
+	}
+}
+
+func TestUnresolvableTypes(t *testing.T) {
+	gen := newGenerator(&ast.Package{Name: "main", Files: map[string]*ast.File{}}, nil)
+	tests := []ast.Expr{
+		&ast.ChanType{Dir: ast.SEND, Value: &ast.Ident{Name: "int"}},
+		&ast.BadExpr{},
+		&ast.ParenExpr{X: &ast.Ident{Name: "int"}},
+	}
+	for _, nativeType := range tests {
+		_, _, err := gen.ResolveValue(&ast.Ident{Name: "value"}, &ast.Ident{Name: "jsValue"}, nativeType, nil)
+		if err == nil {
+			t.Errorf("Expected an error resolving %T", nativeType)
+		}
+	}
+
+	// the error fails generation rather than panicking
+	if _, err := tryGenerate("package main\n\nfunc Send(ch chan<- int) {}\n", nil); err == nil {
+		t.Errorf("Expected an error generating a wrapper for a send-only channel parameter")
+	}
+}
+
+func TestAnyParams(t *testing.T) {
+	src := `package main
+
+import "syscall/js"
+
+type Opaque interface{}
+
+func Kind(v any, o Opaque, e interface{}) string {
+	return v.(js.Value).Type().String() + " " + o.(js.Value).Get("n").String() + " " + e.(js.Value).Type().String()
+}
+`
+	// the js values are held as they are
+	if got := runWasm(t, src, nil, `Kind(() => 1, {n: "x"}, null)`); got != "function x null" {
+		t.Errorf("Expected function x null, got %s", got)
+	}
+
+	_, err := tryGenerate(`package main
+
+type Shape interface {
+	Area() float64
+}
+
+func Area(s Shape) float64 {
+	return s.Area()
+}
+`, nil)
+	if err == nil || !strings.Contains(err.Error(), "RegisterType") {
+		t.Errorf("Expected an error pointing at RegisterType, got %v", err)
+	}
+}
+
+func TestCancelFuncParams(t *testing.T) {
+	src := `package main
+
+import "context"
+
+func Stop(cancel context.CancelFunc) {
+	cancel()
+}
+`
+	// the js callback runs when go cancels, missing callbacks being ignored
+	script := `let cancelled = 0; Stop(() => cancelled++); Stop(undefined); String(cancelled)`
+	if got := runWasm(t, src, nil, script); got != "1" {
+		t.Errorf("Expected 1, got %s", got)
+	}
+}
+
+func TestRegisterEnumObject(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+type Status int
+
+func Describe(s Status) string {
+	return fmt.Sprint(int(s))
+}
+`
+	config := NewConfig()
+	config.RegisterEnumObject("Status", "value")
+	// objects hold the value in their field, other js values are the value itself
+	if got := runWasm(t, src, config, `[Describe({value: 1, label: "Active"}), Describe(2)].join(",")`); got != "1,2" {
+		t.Errorf("Expected 1,2, got %s", got)
+	}
+}
+
+func TestEnumMapKeys(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+type Color string
+
+const (
+	Red  Color = "red"
+	Blue Color = "blue"
+)
+
+type Status int
+
+func Reds(counts map[Color]int) int {
+	return counts[Red]
+}
+
+func Active(counts map[Status]int) string {
+	return fmt.Sprint(counts)
+}
+`
+	config := NewConfig()
+	config.RegisterEnumObject("Color", "value")
+	config.RegisterEnumObject("Status", "value")
+	// property names are strings, converted to numbers for numeric enums
+	got := runWasm(t, src, config, `[Reds({red: 1, blue: 2}), Active({1: 3, 2: 4})].join(",")`)
+	if want := "1,map[1:3 2:4]"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestUnnamedParams(t *testing.T) {
+	src := `package main
+
+import "strings"
+
+func Repeat(string, int) string {
+	return "ignored"
+}
+
+func Pad(s string, _ int, n int) string {
+	return s + strings.Repeat(".", n)
+}
+
+func Greet(name string, title *string) string {
+	if title == nil {
+		return name
+	}
+	return *title + " " + name
+}
+`
+	// missing trailing args are undefined, so optional params resolve as absent
+	script := `const results = [];
+for (const call of [() => Repeat("a", 2), () => Pad("a", 0, 2), () => Greet("ann"), () => Greet("ann", "dr"), () => Pad("a")]) {
+	try {
+		results.push(call());
+	} catch (e) {
+		results.push(e.message);
+	}
+}
+results.join(",")`
+	tests := []struct {
+		name   string
+		strict bool
+		want   string
+	}{
+		{"lenient", false, "ignored,a..,ann,dr ann,syscall/js: call of Value.Int on undefined"},
+		{"strict", true, "ignored,a..,Expected 2 arguments, got 1,dr ann,Expected 3 arguments, got 1"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := NewConfig()
+			config.StrictArity = test.strict
+			config.RecoverPanics = true
+			got := runWasm(t, src, config, script)
+			if got != test.want {
+				t.Errorf("Expected %s, got %s", test.want, got)
+			}
+		})
+	}
+}
+
+func TestMergePrototype(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+type Limits struct {
+	Retries int
+	Timeout int
+}
+
+func Settings(settings map[string]int) string {
+	return fmt.Sprint(settings)
+}
+
+//wasm:flatten limits
+func Flatten(limits Limits) string {
+	return fmt.Sprint(limits.Retries, limits.Timeout)
+}
+`
+	script := `const base = {retries: 3, timeout: 10};
+const own = Object.create(base);
+own.timeout = 30;
+const flat = Object.create({Retries: 3, Timeout: 10});
+flat.Timeout = 30;
+[Settings(own), Flatten(flat)].join(",")`
+
+	config := NewConfig()
+	config.MergePrototype = true
+	got := runWasm(t, src, config, script)
+	if want := "map[retries:3 timeout:30],3 30"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	got = runWasm(t, src, nil, `const own = Object.create({retries: 3}); own.timeout = 30; Settings(own)`)
+	if want := "map[timeout:30]"; got != want {
+		t.Errorf("Expected own properties only without MergePrototype, got %s", got)
+	}
+}
+
+func TestRecursiveTypes(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+type Node struct {
+	Value int
+	Next  *Node
+}
+
+func Values(n Node) string {
+	values := fmt.Sprint(n.Value)
+	for next := n.Next; next != nil; next = next.Next {
+		values += fmt.Sprint(" ", next.Value)
+	}
+
+	return values
+}
+`
+	code := generate(t, src, nil)
+	if !strings.Contains(code, "func wasmResolveNode(value js.Value) Node") {
+		t.Errorf("Expected a wasmResolveNode function, got:\n%s", code)
+	}
+
+	got := runWasm(t, src, nil, `Values({Value: 1, Next: {Value: 2, Next: {Value: 3}}})`)
+	if got != "1 2 3" {
+		t.Errorf("Expected 1 2 3, got %s", got)
+	}
+}
+
+func TestMaxDepthRecursive(t *testing.T) {
+	src := `package main
+
+type Node struct {
+	Value int
+	Next  *Node
+}
+
+func Length(n Node) int {
+	length := 1
+	for next := n.Next; next != nil; next = next.Next {
+		length++
+	}
+
+	return length
+}
+`
+	script := `const list = (n) => n == 1 ? {Value: n} : {Value: n, Next: list(n - 1)};
+// the short list resolves before the long one throws
+Length(list(2)) == 2 && Length(list(5))`
+	for _, named := range []bool{false, true} {
+		config := NewConfig()
+		config.MaxDepth = 3
+		config.EmitNamedResolvers = named
+		got := runWasmThrows(t, src, config, script)
+		if want := "Maximum resolution depth of 3 exceeded"; got != want {
+			t.Errorf("Expected %s with EmitNamedResolvers %v, got %s", want, named, got)
+		}
+	}
+}
+
+func TestRegisterFieldResolver(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+type Order struct {
+	Total int
+	Count int
+}
+
+type Invoice struct {
+	Order Order
+	Total int
+}
+
+func Describe(invoice Invoice) string {
+	return fmt.Sprint(invoice.Order.Total, invoice.Order.Count, invoice.Total)
+}
+`
+	config := NewConfig()
+	// totals are passed in dollars and resolved into cents:
+	// 	name := int(math.Round(jsValue.Float() * 100))
+	config.RegisterFieldResolver("Order.Total", func(name *ast.Ident, jsValue ast.Expr) (ast.Expr, []ast.Stmt, error) {
+		return name, []ast.Stmt{
+			&ast.AssignStmt{
+				Lhs: []ast.Expr{name},
+				Tok: token.DEFINE,
+				Rhs: []ast.Expr{
+					&ast.CallExpr{
+						Fun: &ast.Ident{Name: "int"},
+						Args: []ast.Expr{
+							&ast.CallExpr{
+								Fun: &ast.SelectorExpr{X: &ast.Ident{Name: "math"}, Sel: &ast.Ident{Name: "Round"}},
+								Args: []ast.Expr{
+									&ast.BinaryExpr{
+										X:  &ast.CallExpr{Fun: &ast.SelectorExpr{X: jsValue, Sel: &ast.Ident{Name: "Float"}}},
+										Op: token.MUL,
+										Y:  &ast.BasicLit{Kind: token.INT, Value: "100"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}, nil
+	}, "math")
+
+	// Invoice.Total and Order.Count are resolved as ints
+	got := runWasm(t, src, config, `Describe({Order: {Total: 12.34, Count: 2}, Total: 5})`)
+	if want := "1234 2 5"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestNamedResolversEmittedOnce(t *testing.T) {
+	src := `package main
+
+type Point struct {
+	X, Y int
+}
+
+func Sum(p Point) int {
+	return p.X + p.Y
+}
+
+func Dist(a, b Point) int {
+	return b.X - a.X + b.Y - a.Y
+}
+`
+	config := NewConfig()
+	config.EmitNamedResolvers = true
+	out := generate(t, src, config)
+	if count := strings.Count(out, "func wasmResolvePoint("); count != 1 {
+		t.Errorf("Expected wasmResolvePoint to be declared once, got %d:\n%s", count, out)
+	}
+	if count := strings.Count(out, "wasmResolvePoint(args["); count != 3 {
+		t.Errorf("Expected wasmResolvePoint to be called for each of 3 parameters, got %d:\n%s", count, out)
+	}
+	if strings.Count(out, `.Get("X")`) != 1 {
+		t.Errorf("Expected Point's fields to be resolved in its function only:\n%s", out)
+	}
+
+	got := runWasm(t, src, config, `[Sum({X: 1, Y: 2}), Dist({X: 1, Y: 1}, {X: 4, Y: 5})].join(",")`)
+	if got != "3,7" {
+		t.Errorf("Expected 3,7, got %s", got)
+	}
+}
+
+
+func TestBigInt64(t *testing.T) {
+	src := `package main
+
+func Next(n int64) int64 {
+	return n + 1
+}
+
+func NextUnsigned(n uint64) uint64 {
+	return n + 1
+}
+`
+	script := `const results = [];
+for (const call of [
+	() => Next(2n ** 60n + 1n),
+	() => Next(2 ** 53),
+	() => Next(-5),
+	() => Next(1.5),
+	() => Next(2 ** 63),
+	() => NextUnsigned(2n ** 63n),
+	() => NextUnsigned(-1),
+	() => NextUnsigned(2 ** 64),
+]) {
+	try {
+		results.push(String(call()));
+	} catch (e) {
+		results.push(e.message);
+	}
+}
+results.join(",")`
+	config := NewConfig()
+	config.BigIntFor64 = true
+	config.RecoverPanics = true
+	got := runWasm(t, src, config, script)
+	want := "1152921504606846978,9007199254740993,-4," +
+		"Invalid int64: 1.5 is not an integer in range,Invalid int64: 9.223372036854776e+18 is not an integer in range," +
+		"9223372036854775809,Invalid uint64: -1 is not an integer in range,Invalid uint64: 1.8446744073709552e+19 is not an integer in range"
+	if got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestPointerSlices(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+type Series struct {
+	Points []int
+}
+
+func Total(nums *[]int) int {
+	total := 0
+	for _, n := range *nums {
+		total += n
+	}
+	return total
+}
+
+func Optional(nums []*int) string {
+	values := make([]any, len(nums))
+	for i, n := range nums {
+		values[i] = "nil"
+		if n != nil {
+			values[i] = *n
+		}
+	}
+	return fmt.Sprint(values)
+}
+
+func Shared(nums []*int) bool {
+	return nums[0] != nums[1]
+}
+
+func Lengths(series []Series) string {
+	return fmt.Sprint(series)
+}
+`
+	script := `[Total([1, 2, 3]), Optional([1, null, 3, undefined]), Shared([5, 5]), Lengths([{Points: [1, 2]}, {Points: []}])].join(",")`
+	got := runWasm(t, src, nil, script)
+	if want := "6,[1 nil 3 nil],true,[{[1 2]} {[]}]"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestFuncParams(t *testing.T) {
+	src := `package main
+
+func Apply(nums []int, fn func(int) int) []int {
+	for i, n := range nums {
+		nums[i] = fn(n)
+	}
+	return nums
+}
+
+func Each(names []string, visit func(name string, i int)) {
+	for i, name := range names {
+		visit(name, i)
+	}
+}
+`
+	script := `const seen = [];
+Each(["a", "b"], (name, i) => seen.push(name + i));
+[Apply([1, 2, 3], (n) => n * 10).join(" "), seen.join(" ")].join(",")`
+	got := runWasm(t, src, nil, script)
+	if want := "10 20 30,a0 b1"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestPullChannels(t *testing.T) {
+	// Pull is named after the pull helper, which its wrapper mustn't collide with
+	src := `package main
+
+import "fmt"
+
+func Pull(ch chan int) int {
+	sum := 0
+	for n := range ch {
+		sum += n
+	}
+
+	return sum
+}
+
+func Drain(ch <-chan string) string {
+	var items []string
+	for item := range ch {
+		items = append(items, item)
+	}
+
+	return fmt.Sprint(items)
+}
+`
+	script := `const items = [1, 2, 3];
+const pull = () => items.length ? {value: items.shift()} : {done: true};
+function* letters() {
+	yield "a";
+	yield "b";
+}
+[Pull(pull), items.length, Drain(letters()), Drain(() => undefined)].join(",")`
+	got := runWasm(t, src, nil, script)
+	if want := "6,0,[a b],[]"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestErrorParams(t *testing.T) {
+	src := `package main
+
+import "strings"
+
+func Messages(errs []error) string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		if err == nil {
+			messages[i] = "nil"
+		} else {
+			messages[i] = err.Error()
+		}
+	}
+	return strings.Join(messages, " ")
+}
+`
+	got := runWasm(t, src, nil, `Messages([new TypeError("bad type"), null, "plain", undefined, {message: "custom"}])`)
+	if want := "bad type nil plain nil custom"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestLazyStructs(t *testing.T) {
+	src := `package main
+
+type Order struct {
+	ID    int    ` + "`js:\"id,required\"`" + `
+	Note  string ` + "`js:\"note\"`" + `
+	Items []int  ` + "`js:\"items\"`" + `
+}
+
+func Twice(o *OrderLazyWasm) int {
+	return o.ID() + o.ID()
+}
+
+func Count(o OrderLazyWasm) int {
+	return len(o.Items())
+}
+`
+	// each property counts its reads, so only the getters called read js
+	script := `const reads = {};
+const order = {};
+for (const [key, value] of Object.entries({id: 4, note: "rush", items: [1, 2]})) {
+	Object.defineProperty(order, key, {get: () => { reads[key] = (reads[key] || 0) + 1; return value; }});
+}
+[Twice(order), JSON.stringify(reads), Count(order), JSON.stringify(reads)].join(" ")`
+	got := runWasm(t, src, nil, script)
+	if want := `8 {"id":1} 2 {"id":1,"items":1}`; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	if got := runWasmThrows(t, src, nil, `Twice({note: "rush"})`); got != "Missing required field id" {
+		t.Errorf("Expected Missing required field id, got %s", got)
+	}
+}
+
+func TestConstantArrayLengths(t *testing.T) {
+	src := `package main
+
+import "wasmtest/consts"
+
+const Local = 2
+
+type Grid struct {
+	Cells [consts.N]int
+}
+
+func Sum(ns [consts.N]int) int {
+	return ns[0] + ns[1] + ns[2]
+}
+
+func SumLocal(ns [Local]int) int {
+	return ns[0] + ns[1]
+}
+
+func Last(g Grid) int {
+	return g.Cells[consts.N-1]
+}
+`
+	mod := newWasmModule(t, src, nil, `[Sum([1, 2, 3]), SumLocal([4, 5]), Last({Cells: [7, 8, 9]})].join(",")`, map[string]string{
+		"consts/consts.go": "package consts\n\nconst N = 3\n",
+	})
+	mod.vet(t)
+	got, err := mod.run(t)
+	if err != nil {
+		t.Fatalf("Error running wasm: %v\n%s", err, got)
+	}
+	if got != "6,9,9" {
+		t.Errorf("Expected 6,9,9, got %s", got)
+	}
+}
+
+func TestArrayLengthMismatch(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+func Show(ns [3]int) string {
+	return fmt.Sprint(ns)
+}
+`
+	script := `const results = [];
+for (const ns of [[1, 2], [1, 2, 3], [1, 2, 3, 4]]) {
+	try {
+		results.push(Show(ns));
+	} catch (e) {
+		results.push(e.message);
+	}
+}
+results.join(",")`
+	tests := []struct {
+		name   string
+		policy ArrayLengthMismatch
+		want   string
+	}{
+		{"pad", PadArrays, "[1 2 0],[1 2 3],[1 2 3]"},
+		{"truncate", TruncateArrays, "Expected an array of 3 elements, got 2,[1 2 3],[1 2 3]"},
+		{"strict", StrictArrays, "Expected an array of 3 elements, got 2,[1 2 3],Expected an array of 3 elements, got 4"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := NewConfig()
+			config.ArrayLengthMismatch = test.policy
+			config.RecoverPanics = true
+			got := runWasm(t, src, config, script)
+			if got != test.want {
+				t.Errorf("Expected %s, got %s", test.want, got)
+			}
+		})
+	}
+}
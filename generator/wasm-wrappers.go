@@ -4,13 +4,17 @@ import (
 	"fmt"
 	"go/ast"
 	"go/token"
+	"go/types"
+	"sort"
+	"strconv"
 	"strings"
 
 	"golang.org/x/tools/go/ast/astutil"
 )
 
 // returns a file containing wasm wrappers for each of the top-level function declarations in the pkg
-// and a wasmMain function that exposes each of the exported functions to js
+// and a wasmMain function that exposes each of the exported functions to js.
+// //wasm: directives are read from function docs, so pkg should be parsed with parser.ParseComments
 func GenerateWrapperFile(pkg *ast.Package, config *Config) (*ast.File, error) {
 	if pkg == nil {
 		return nil, fmt.Errorf("Pkg can't be nil")
@@ -22,7 +26,7 @@ func GenerateWrapperFile(pkg *ast.Package, config *Config) (*ast.File, error) {
 	for _, file := range pkg.Files {
 		for _, decl := range file.Decls {
 			if fn, ok := decl.(*ast.FuncDecl); ok {
-				if !fn.Name.IsExported() || strings.HasSuffix(fn.Name.Name, "Wasm") {
+				if !isExposed(fn) {
 					continue
 				}
 
@@ -37,15 +41,93 @@ func GenerateWrapperFile(pkg *ast.Package, config *Config) (*ast.File, error) {
 		}
 	}
 
+	if gen.config.BatchCalls {
+		funcWrappers = append(funcWrappers, gen.batchWrapperFunc(funcSignatures))
+	}
+
+	if gen.config.ResolverStats {
+		funcWrappers = append(funcWrappers, gen.resolverStatsDecls()...)
+	}
+
+	// resolvers generated for recursive types
+	aliasNames := make([]string, 0, len(gen.aliasResolvers))
+	for name := range gen.aliasResolvers {
+		aliasNames = append(aliasNames, name)
+	}
+	sort.Strings(aliasNames)
+	for _, name := range aliasNames {
+		funcWrappers = append(funcWrappers, gen.aliasResolvers[name])
+	}
+
+	// lazy struct types and their getters
+	lazyNames := make([]string, 0, len(gen.lazyTypes))
+	for name := range gen.lazyTypes {
+		lazyNames = append(lazyNames, name)
+	}
+	sort.Strings(lazyNames)
+	for _, name := range lazyNames {
+		funcWrappers = append(funcWrappers, gen.lazyTypes[name]...)
+	}
+
+	helperDecls, err := gen.helperDecls()
+	if err != nil {
+		return nil, err
+	}
+
 	wrapperFile := &ast.File{
 		Name:  &ast.Ident{Name: pkg.Name},
-		Decls: append(funcWrappers, gen.wasmMainFunc(funcSignatures)),
+		Decls: append(append(funcWrappers, gen.wasmMainFunc(funcSignatures)), helperDecls...),
+	}
+
+	if err := checkDeclNames(wrapperFile.Decls); err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	astutil.AddNamedImport(fset, wrapperFile, gen.config.JSPackageIdent, gen.jsPackage())
+	for path := range gen.imports {
+		if path != gen.jsPackage() {
+			astutil.AddImport(fset, wrapperFile, path)
+		}
 	}
 
-	astutil.AddImport(token.NewFileSet(), wrapperFile, "syscall/js")
 	return wrapperFile, nil
 }
 
+// returns an error naming the first top-level name declared twice by the generated declarations,
+// e.g. by the wrapper of a function OnCallStart and the OnCallStartWasm hook of TraceCalls
+func checkDeclNames(decls []ast.Decl) error {
+	declared := make(map[string]bool)
+	for _, decl := range decls {
+		var names []*ast.Ident
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv == nil {
+				names = append(names, decl.Name)
+			}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					names = append(names, spec.Name)
+				case *ast.ValueSpec:
+					names = append(names, spec.Names...)
+				}
+			}
+		}
+
+		for _, name := range names {
+			if declared[name.Name] {
+				return fmt.Errorf("\"%s\" is declared twice in the generated file, rename the function it's generated for", name.Name)
+			}
+
+			declared[name.Name] = true
+		}
+	}
+
+	return nil
+}
+
 // returns a wrapper function that:
 // transforms dynamic js args into the given static function signature,
 // calls the given function with the resolved arguments,
@@ -54,6 +136,9 @@ func GenerateWrapperFile(pkg *ast.Package, config *Config) (*ast.File, error) {
 // wasm wrapper signature:
 // 	func exampleWasm(this js.Value, args []js.Value) any { ...
 func (gen *generator) wasmWrapperFunc(fn *ast.FuncDecl) (*ast.FuncDecl, error) {
+	gen.directives = parseDirectives(fn.Doc)
+	gen.this = &ast.Ident{Name: "this"}
+	defer func() { gen.this = nil }()
 	args, argResolvers, err := gen.resolveFuncArgs(fn.Type.Params)
 	if err != nil {
 		return nil, err
@@ -66,6 +151,129 @@ func (gen *generator) wasmWrapperFunc(fn *ast.FuncDecl) (*ast.FuncDecl, error) {
 		},
 		Args: args,
 	}
+	if params := fn.Type.Params.List; len(params) > 0 {
+		if _, ok := params[len(params)-1].Type.(*ast.Ellipsis); ok {
+			funcCall.Ellipsis = 1
+		}
+	}
+
+	if duration := gen.getDirective("duration"); duration != nil && duration.value != "string" && duration.value != "number" {
+		return nil, fmt.Errorf("Invalid //wasm:duration value \"%s\", expected string or number", duration.value)
+	}
+
+	var result ast.Expr = funcCall
+	results := fn.Type.Results
+	returnsError := results.NumFields() > 0 && types.ExprString(results.List[len(results.List)-1].Type) == "error"
+	if gen.getDirective("base64") != nil {
+		if fn.Type.Results.NumFields() != 1 || types.ExprString(fn.Type.Results.List[0].Type) != "[]byte" {
+			return nil, fmt.Errorf("//wasm:base64 requires a single []byte result")
+		}
+
+		// bytes are returned as a base64 string:
+		// 	return base64.StdEncoding.EncodeToString(example(...))
+		gen.imports["encoding/base64"] = true
+		result = &ast.CallExpr{
+			Fun: &ast.SelectorExpr{
+				X: &ast.SelectorExpr{
+					X:   &ast.Ident{Name: "base64"},
+					Sel: &ast.Ident{Name: "StdEncoding"},
+				},
+				Sel: &ast.Ident{Name: "EncodeToString"},
+			},
+			Args: []ast.Expr{funcCall},
+		}
+	} else if fn.Type.Results.NumFields() == 1 && types.ExprString(fn.Type.Results.List[0].Type) == "json.RawMessage" {
+		// raw json is returned parsed:
+		// 	return js.Global().Get("JSON").Call("parse", string(example(...)))
+		result = &ast.CallExpr{
+			Fun: &ast.SelectorExpr{
+				X: &ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X: &ast.CallExpr{
+							Fun: &ast.SelectorExpr{
+								X:   gen.jsIdent(),
+								Sel: &ast.Ident{Name: "Global"},
+							},
+						},
+						Sel: &ast.Ident{Name: "Get"},
+					},
+					Args: []ast.Expr{&ast.BasicLit{Kind: token.STRING, Value: `"JSON"`}},
+				},
+				Sel: &ast.Ident{Name: "Call"},
+			},
+			Args: []ast.Expr{
+				&ast.BasicLit{Kind: token.STRING, Value: `"parse"`},
+				&ast.CallExpr{
+					Fun:  &ast.Ident{Name: "string"},
+					Args: []ast.Expr{funcCall},
+				},
+			},
+		}
+	} else if fn.Type.Results.NumFields() == 1 && !returnsError {
+		// results are encoded into values js.ValueOf accepts,
+		// composite results are first assigned so the encoder can read them:
+		// 	exampleResult := example(...)
+		// 	exampleResultJS := make([]any, len(exampleResult))
+		// 	...
+		// 	return exampleResultJS
+		resultType := fn.Type.Results.List[0].Type
+		resultName := &ast.Ident{Name: strings.ToLower(fn.Name.Name[:1]) + fn.Name.Name[1:] + "Result"}
+		// basic results are encoded in place of the call
+		var inline bool
+		switch underlying := gen.underlyingType(resultType).(type) {
+		case *ast.Ident:
+			inline = !strings.HasPrefix(underlying.Name, "complex")
+		case *ast.SelectorExpr:
+			inline = true
+		}
+
+		if gen.getDirective("reuse") != nil {
+			// struct results are encoded into the same js object on every call
+			structType := gen.underlyingType(resultType)
+			if pointer, ok := structType.(*ast.StarExpr); ok {
+				structType = gen.underlyingType(pointer.X)
+			}
+
+			if _, ok := structType.(*ast.StructType); !ok {
+				return nil, fmt.Errorf("//wasm:reuse requires a struct or struct pointer result")
+			}
+
+			gen.reuseObject = fn.Name.Name
+			defer func() { gen.reuseObject = "" }()
+		}
+
+		if inline {
+			encoded, _, err := gen.EncodeValue(resultName, funcCall, resultType)
+			if err != nil {
+				return nil, fmt.Errorf("Unencodable result of %s: %v", fn.Name.Name, err)
+			}
+
+			result = encoded
+		} else {
+			encoded, encoder, err := gen.EncodeValue(&ast.Ident{Name: resultName.Name + "JS"}, resultName, resultType)
+			if err != nil {
+				return nil, fmt.Errorf("Unencodable result of %s: %v", fn.Name.Name, err)
+			}
+
+			argResolvers = append(argResolvers, &ast.AssignStmt{
+				Lhs: []ast.Expr{resultName},
+				Tok: token.DEFINE,
+				Rhs: []ast.Expr{funcCall},
+			})
+			argResolvers = append(argResolvers, encoder...)
+			result = encoded
+		}
+	}
+
+	if fn.Type.Results.NumFields() > 1 || returnsError {
+		var resultEncoder []ast.Stmt
+		result, resultEncoder, err = gen.encodeResults(fn, funcCall)
+		if err != nil {
+			return nil, err
+		}
+
+		argResolvers = append(argResolvers, resultEncoder...)
+	}
 
 	var returnStmt *ast.ReturnStmt
 	if fn.Type.Results.NumFields() == 0 {
@@ -75,84 +283,265 @@ func (gen *generator) wasmWrapperFunc(fn *ast.FuncDecl) (*ast.FuncDecl, error) {
 		}
 	} else {
 		returnStmt = &ast.ReturnStmt{
-			Results: []ast.Expr{funcCall},
+			Results: []ast.Expr{result},
 		}
 	}
 
-	return &ast.FuncDecl{
-		Name: &ast.Ident{Name: gen.wrapperName(fn.Name.Name)},
-		Type: &ast.FuncType{
-			Params: &ast.FieldList{
-				List: []*ast.Field{
-					{
-						Type: &ast.SelectorExpr{
-							X:   &ast.Ident{Name: "js"},
-							Sel: &ast.Ident{Name: "Value"},
-						},
-						Names: []*ast.Ident{
-							{Name: "this"},
+	body := append(argResolvers, returnStmt)
+	if gen.config.TraceCalls {
+		// the call hooks run around the body, within the promise of async wrappers:
+		// 	return wasmTraceCall("example", func() any { ... })
+		gen.useHelper("trace")
+		body = []ast.Stmt{
+			&ast.ReturnStmt{
+				Results: []ast.Expr{
+					&ast.CallExpr{
+						Fun: &ast.Ident{Name: "wasmTraceCall"},
+						Args: []ast.Expr{
+							&ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(fn.Name.Name)},
+							bodyFuncLit(body),
 						},
 					},
-					{
-						Type: &ast.ArrayType{
-							Elt: &ast.SelectorExpr{
-								X:   &ast.Ident{Name: "js"},
-								Sel: &ast.Ident{Name: "Value"},
-							},
-						},
-						Names: []*ast.Ident{
-							{Name: "args"},
-						},
+				},
+			},
+		}
+	}
+
+	if gen.config.EnvelopeReturns {
+		// results and errors are returned in an {ok, value, error} object rather than thrown:
+		// 	return wasmEnvelope(func() any { ... })
+		gen.useHelper("envelope")
+		body = []ast.Stmt{
+			&ast.ReturnStmt{
+				Results: []ast.Expr{
+					&ast.CallExpr{
+						Fun:  &ast.Ident{Name: "wasmEnvelope"},
+						Args: []ast.Expr{bodyFuncLit(body)},
 					},
 				},
 			},
-			Results: &ast.FieldList{
-				List: []*ast.Field{
-					{Type: &ast.Ident{Name: "any"}},
+		}
+	}
+
+	if gen.config.Async {
+		// the body runs on its own goroutine so it can block on promises:
+		// 	return wasmPromise(func() any { ... })
+		gen.useHelper("promise")
+		body = []ast.Stmt{
+			&ast.ReturnStmt{
+				Results: []ast.Expr{
+					&ast.CallExpr{
+						Fun:  &ast.Ident{Name: "wasmPromise"},
+						Args: []ast.Expr{bodyFuncLit(body)},
+					},
 				},
 			},
-		},
+		}
+	}
+
+	funcType := gen.wrapperFuncType()
+	if gen.recoversPanics() {
+		body = gen.recoverPanics(funcType, body)
+	}
+
+	return &ast.FuncDecl{
+		Name: &ast.Ident{Name: gen.wrapperName(fn.Name.Name)},
+		Type: funcType,
 		Body: &ast.BlockStmt{
-			List: append(argResolvers, returnStmt),
+			List: body,
 		},
 	}, nil
 }
 
-// returns an new function called "wasmMain" that exposes each of the given functions to js
-func (gen *generator) wasmMainFunc(funcs map[string]*ast.FuncType) *ast.FuncDecl {
-	var i int
-	jsGlobalDecls := make([]ast.Stmt, len(funcs))
-	for name := range funcs {
-		jsGlobalDecls[i] = &ast.ExprStmt{
-			X: &ast.CallExpr{
-				Fun: &ast.SelectorExpr{
-					X: &ast.CallExpr{
+// names the result of a wrapper's funcType and returns its body recovering panics
+// into a result the registered js function throws:
+// 	func exampleWasm(this js.Value, args []js.Value) (result any) {
+// 		defer wasmRecover(&result)
+func (gen *generator) recoverPanics(funcType *ast.FuncType, body []ast.Stmt) []ast.Stmt {
+	result := &ast.Ident{Name: "result"}
+	funcType.Results.List[0].Names = []*ast.Ident{result}
+	gen.useHelper("recover")
+	return append([]ast.Stmt{
+		&ast.DeferStmt{
+			Call: &ast.CallExpr{
+				Fun:  &ast.Ident{Name: "wasmRecover"},
+				Args: []ast.Expr{&ast.UnaryExpr{Op: token.AND, X: result}},
+			},
+		},
+	}, body...)
+}
+
+// returns an expression packaging the results of a function returning several values or an error:
+// results ending in an error are returned as a {value, error} object, the error being its message or null,
+// and the value an array when there are several others. a lone error is returned as its message or null.
+// other results are returned as an array.
+// non-nil errors are thrown as js Errors with ErrorsAsExceptions,
+// and async and envelope wrappers return them as is, to be rejected or enveloped
+//
+// generated encoder:
+// 	exampleResult0, exampleResult1 := example(...)
+// 	var exampleError any
+// 	if exampleResult1 != nil {
+// 		exampleError = exampleResult1.Error()
+// 	}
+// 	return map[string]any{"value": ..., "error": exampleError}
+func (gen *generator) encodeResults(fn *ast.FuncDecl, funcCall ast.Expr) (ast.Expr, []ast.Stmt, error) {
+	baseName := strings.ToLower(fn.Name.Name[:1]) + fn.Name.Name[1:] + "Result"
+	resultNames := make([]ast.Expr, 0, fn.Type.Results.NumFields())
+	resultTypes := make([]ast.Expr, 0, fn.Type.Results.NumFields())
+	for _, field := range fn.Type.Results.List {
+		count := len(field.Names)
+		if count == 0 {
+			count = 1
+		}
+
+		for i := 0; i < count; i++ {
+			resultNames = append(resultNames, &ast.Ident{Name: baseName + strconv.Itoa(len(resultTypes))})
+			resultTypes = append(resultTypes, field.Type)
+		}
+	}
+
+	encoder := []ast.Stmt{
+		&ast.AssignStmt{
+			Lhs: resultNames,
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{funcCall},
+		},
+	}
+
+	var errResult ast.Expr
+	if last := len(resultTypes) - 1; types.ExprString(resultTypes[last]) == "error" {
+		errResult = resultNames[last]
+		resultNames, resultTypes = resultNames[:last], resultTypes[:last]
+	}
+
+	values := make([]ast.Expr, len(resultNames))
+	for i, resultName := range resultNames {
+		encoded, valueEncoder, err := gen.EncodeValue(&ast.Ident{Name: resultName.(*ast.Ident).Name + "JS"}, resultName, resultTypes[i])
+		if err != nil {
+			return nil, nil, fmt.Errorf("Unencodable result of %s: %v", fn.Name.Name, err)
+		}
+
+		values[i] = encoded
+		encoder = append(encoder, valueEncoder...)
+	}
+
+	var value ast.Expr = &ast.CompositeLit{
+		Type: &ast.ArrayType{Elt: &ast.Ident{Name: "any"}},
+		Elts: values,
+	}
+	if errResult == nil {
+		return value, encoder, nil
+	} else if len(values) == 0 {
+		value = &ast.Ident{Name: "nil"}
+	} else if len(values) == 1 {
+		value = values[0]
+	}
+
+	notNil := &ast.BinaryExpr{X: errResult, Op: token.NEQ, Y: &ast.Ident{Name: "nil"}}
+	if gen.config.ErrorsAsExceptions {
+		// 	if exampleResult1 != nil {
+		// 		panic(js.Global().Get("Error").New(exampleResult1.Error()))
+		// 	}
+		// 	return ...
+		return value, append(encoder, &ast.IfStmt{
+			Cond: notNil,
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					gen.throwStmt(&ast.CallExpr{
 						Fun: &ast.SelectorExpr{
-							X:   &ast.Ident{Name: "js"},
-							Sel: &ast.Ident{Name: "Global"},
+							X:   errResult,
+							Sel: &ast.Ident{Name: "Error"},
 						},
-					},
-					Sel: &ast.Ident{Name: "Set"},
+					}),
+				},
+			},
+		}), nil
+	} else if gen.config.Async || gen.config.EnvelopeReturns {
+		// 	if exampleResult1 != nil {
+		// 		return exampleResult1
+		// 	}
+		// 	return ...
+		return value, append(encoder, &ast.IfStmt{
+			Cond: notNil,
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					&ast.ReturnStmt{Results: []ast.Expr{errResult}},
 				},
-				Args: []ast.Expr{
-					&ast.BasicLit{
-						Kind:  token.STRING,
-						Value: "\"" + name + "\"",
+			},
+		}), nil
+	}
+
+	errMessage := &ast.Ident{Name: strings.ToLower(fn.Name.Name[:1]) + fn.Name.Name[1:] + "Error"}
+	encoder = append(encoder,
+		&ast.DeclStmt{
+			Decl: &ast.GenDecl{
+				Tok: token.VAR,
+				Specs: []ast.Spec{
+					&ast.ValueSpec{
+						Names: []*ast.Ident{errMessage},
+						Type:  &ast.Ident{Name: "any"},
 					},
-					&ast.CallExpr{
-						Fun: &ast.SelectorExpr{
-							X:   &ast.Ident{Name: "js"},
-							Sel: &ast.Ident{Name: "FuncOf"},
+				},
+			},
+		},
+		&ast.IfStmt{
+			Cond: notNil,
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					&ast.AssignStmt{
+						Lhs: []ast.Expr{errMessage},
+						Tok: token.ASSIGN,
+						Rhs: []ast.Expr{
+							&ast.CallExpr{
+								Fun: &ast.SelectorExpr{
+									X:   errResult,
+									Sel: &ast.Ident{Name: "Error"},
+								},
+							},
 						},
-						Args: []ast.Expr{&ast.Ident{Name: gen.wrapperName(name)}},
 					},
 				},
 			},
-		}
+		},
+	)
+
+	if len(values) == 0 {
+		return errMessage, encoder, nil
+	}
+
+	return &ast.CompositeLit{
+		Type: anyMapType(),
+		Elts: []ast.Expr{
+			&ast.KeyValueExpr{
+				Key:   &ast.BasicLit{Kind: token.STRING, Value: `"value"`},
+				Value: value,
+			},
+			&ast.KeyValueExpr{
+				Key:   &ast.BasicLit{Kind: token.STRING, Value: `"error"`},
+				Value: errMessage,
+			},
+		},
+	}, encoder, nil
+}
 
+// returns an new function called "wasmMain" that exposes each of the given functions to js
+func (gen *generator) wasmMainFunc(funcs map[string]*ast.FuncType) *ast.FuncDecl {
+	var i int
+	jsGlobalDecls := make([]ast.Stmt, len(funcs))
+	for name := range funcs {
+		jsGlobalDecls[i] = gen.jsGlobalFunc(name, gen.wrapperName(name))
 		i++
 	}
 
+	if gen.config.BatchCalls {
+		jsGlobalDecls = append(jsGlobalDecls, gen.jsGlobalFunc("__batch", "wasmBatch"))
+	}
+
+	if gen.config.ResolverStats {
+		jsGlobalDecls = append(jsGlobalDecls, gen.jsGlobalFunc("__resolverStats", "wasmResolverStats"))
+	}
+
 	return &ast.FuncDecl{
 		Name: &ast.Ident{Name: "mainWasm"},
 		Type: &ast.FuncType{
@@ -163,3 +552,340 @@ func (gen *generator) wasmMainFunc(funcs map[string]*ast.FuncType) *ast.FuncDecl
 		},
 	}
 }
+
+// reports whether wrappers recover panics and the js functions exposing them throw them.
+// errors thrown with ErrorsAsExceptions are panics too, which would otherwise end the program
+func (gen *generator) recoversPanics() bool {
+	return gen.config.RecoverPanics || gen.config.ErrorsAsExceptions
+}
+
+// returns a statement exposing the given wrapper to js under name:
+// 	js.Global().Set("name", js.FuncOf(wrapper))
+// with RecoverPanics the function is wrapped to throw the panics its wrapper recovers:
+// 	js.Global().Set("name", wasmThrowingFunc(js.FuncOf(wrapper)))
+func (gen *generator) jsGlobalFunc(name string, wrapper string) ast.Stmt {
+	var jsFunc ast.Expr = &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   gen.jsIdent(),
+			Sel: &ast.Ident{Name: "FuncOf"},
+		},
+		Args: []ast.Expr{&ast.Ident{Name: wrapper}},
+	}
+	if gen.recoversPanics() {
+		gen.useHelper("recover")
+		jsFunc = &ast.CallExpr{
+			Fun:  &ast.Ident{Name: "wasmThrowingFunc"},
+			Args: []ast.Expr{jsFunc},
+		}
+	}
+
+	return &ast.ExprStmt{
+		X: &ast.CallExpr{
+			Fun: &ast.SelectorExpr{
+				X: &ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X:   gen.jsIdent(),
+						Sel: &ast.Ident{Name: "Global"},
+					},
+				},
+				Sel: &ast.Ident{Name: "Set"},
+			},
+			Args: []ast.Expr{
+				&ast.BasicLit{
+					Kind:  token.STRING,
+					Value: "\"" + name + "\"",
+				},
+				jsFunc,
+			},
+		},
+	}
+}
+
+// returns a wrapper that takes an array of {fn, args} call specs,
+// dispatches each one to the wrapper of the named function,
+// and returns an array holding the result of each call.
+// anything but an array of calls throws.
+// with RecoverPanics a call that panics has the js Error it would throw as its result
+//
+// batch wrapper signature:
+// 	func wasmBatch(this js.Value, args []js.Value) any { ...
+func (gen *generator) batchWrapperFunc(funcs map[string]*ast.FuncType) *ast.FuncDecl {
+	calls := &ast.Ident{Name: "calls"}
+	call := &ast.Ident{Name: "call"}
+	callIdx := &ast.Ident{Name: "callIdx"}
+	callArgs := &ast.Ident{Name: "callArgs"}
+	argIdx := &ast.Ident{Name: "argIdx"}
+	results := &ast.Ident{Name: "results"}
+	fnName := &ast.Ident{Name: "fnName"}
+	jsCallArgs := &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   call,
+			Sel: &ast.Ident{Name: "Get"},
+		},
+		Args: []ast.Expr{
+			&ast.BasicLit{Kind: token.STRING, Value: "\"args\""},
+		},
+	}
+
+	names := make([]string, 0, len(funcs))
+	for name := range funcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// one case per function, forwarding the call to its wrapper
+	cases := make([]ast.Stmt, 0, len(names)+1)
+	for _, name := range names {
+		var result ast.Expr = &ast.CallExpr{
+			Fun:  &ast.Ident{Name: gen.wrapperName(name)},
+			Args: []ast.Expr{&ast.Ident{Name: "this"}, callArgs},
+		}
+		if gen.recoversPanics() {
+			// 	results[callIdx] = wasmUnmarkResult(exampleWasm(this, callArgs))
+			result = &ast.CallExpr{
+				Fun:  &ast.Ident{Name: "wasmUnmarkResult"},
+				Args: []ast.Expr{result},
+			}
+		}
+
+		cases = append(cases, &ast.CaseClause{
+			List: []ast.Expr{
+				&ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(name)},
+			},
+			Body: []ast.Stmt{
+				&ast.AssignStmt{
+					Lhs: []ast.Expr{&ast.IndexExpr{X: results, Index: callIdx}},
+					Tok: token.ASSIGN,
+					Rhs: []ast.Expr{result},
+				},
+			},
+		})
+	}
+
+	// unknown functions produce a js Error in their result slot
+	cases = append(cases, &ast.CaseClause{
+		Body: []ast.Stmt{
+			&ast.AssignStmt{
+				Lhs: []ast.Expr{&ast.IndexExpr{X: results, Index: callIdx}},
+				Tok: token.ASSIGN,
+				Rhs: []ast.Expr{
+					&ast.CallExpr{
+						Fun: &ast.SelectorExpr{
+							X: &ast.CallExpr{
+								Fun: &ast.SelectorExpr{
+									X: &ast.CallExpr{
+										Fun: &ast.SelectorExpr{
+											X:   gen.jsIdent(),
+											Sel: &ast.Ident{Name: "Global"},
+										},
+									},
+									Sel: &ast.Ident{Name: "Get"},
+								},
+								Args: []ast.Expr{
+									&ast.BasicLit{Kind: token.STRING, Value: "\"Error\""},
+								},
+							},
+							Sel: &ast.Ident{Name: "New"},
+						},
+						Args: []ast.Expr{
+							&ast.BinaryExpr{
+								X:  &ast.BasicLit{Kind: token.STRING, Value: "\"Unknown batch function: \""},
+								Op: token.ADD,
+								Y:  fnName,
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+
+	body := []ast.Stmt{
+		// 	if len(args) < 1 || !js.Global().Get("Array").Call("isArray", args[0]).Bool() {
+		// 		panic(js.Global().Get("Error").New("Expected an array of calls"))
+		// 	}
+		&ast.IfStmt{
+			Cond: &ast.BinaryExpr{
+				X: &ast.BinaryExpr{
+					X:  &ast.CallExpr{Fun: &ast.Ident{Name: "len"}, Args: []ast.Expr{&ast.Ident{Name: "args"}}},
+					Op: token.LSS,
+					Y:  &ast.BasicLit{Kind: token.INT, Value: "1"},
+				},
+				Op: token.LOR,
+				Y: &ast.UnaryExpr{
+					Op: token.NOT,
+					X: &ast.CallExpr{
+						Fun: &ast.SelectorExpr{
+							X: &ast.CallExpr{
+								Fun: &ast.SelectorExpr{
+									X: &ast.CallExpr{
+										Fun: &ast.SelectorExpr{
+											X: &ast.CallExpr{
+												Fun: &ast.SelectorExpr{
+													X:   gen.jsIdent(),
+													Sel: &ast.Ident{Name: "Global"},
+												},
+											},
+											Sel: &ast.Ident{Name: "Get"},
+										},
+										Args: []ast.Expr{
+											&ast.BasicLit{Kind: token.STRING, Value: "\"Array\""},
+										},
+									},
+									Sel: &ast.Ident{Name: "Call"},
+								},
+								Args: []ast.Expr{
+									&ast.BasicLit{Kind: token.STRING, Value: "\"isArray\""},
+									&ast.IndexExpr{
+										X:     &ast.Ident{Name: "args"},
+										Index: &ast.BasicLit{Kind: token.INT, Value: "0"},
+									},
+								},
+							},
+							Sel: &ast.Ident{Name: "Bool"},
+						},
+					},
+				},
+			},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					gen.throwStmt(&ast.BasicLit{Kind: token.STRING, Value: "\"Expected an array of calls\""}),
+				},
+			},
+		},
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{calls},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.IndexExpr{
+					X:     &ast.Ident{Name: "args"},
+					Index: &ast.BasicLit{Kind: token.INT, Value: "0"},
+				},
+			},
+		},
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{results},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun: &ast.Ident{Name: "make"},
+					Args: []ast.Expr{
+						&ast.ArrayType{Elt: &ast.Ident{Name: "any"}},
+						&ast.CallExpr{
+							Fun: &ast.SelectorExpr{
+								X:   calls,
+								Sel: &ast.Ident{Name: "Length"},
+							},
+						},
+					},
+				},
+			},
+		},
+		&ast.RangeStmt{
+			Key: callIdx,
+			Tok: token.DEFINE,
+			X:   results,
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					&ast.AssignStmt{
+						Lhs: []ast.Expr{call},
+						Tok: token.DEFINE,
+						Rhs: []ast.Expr{
+							&ast.CallExpr{
+								Fun: &ast.SelectorExpr{
+									X:   calls,
+									Sel: &ast.Ident{Name: "Index"},
+								},
+								Args: []ast.Expr{callIdx},
+							},
+						},
+					},
+					// copy the js args array into a []js.Value
+					&ast.AssignStmt{
+						Lhs: []ast.Expr{callArgs},
+						Tok: token.DEFINE,
+						Rhs: []ast.Expr{
+							&ast.CallExpr{
+								Fun: &ast.Ident{Name: "make"},
+								Args: []ast.Expr{
+									&ast.ArrayType{
+										Elt: &ast.SelectorExpr{
+											X:   gen.jsIdent(),
+											Sel: &ast.Ident{Name: "Value"},
+										},
+									},
+									&ast.CallExpr{
+										Fun: &ast.SelectorExpr{
+											X:   jsCallArgs,
+											Sel: &ast.Ident{Name: "Length"},
+										},
+									},
+								},
+							},
+						},
+					},
+					&ast.RangeStmt{
+						Key: argIdx,
+						Tok: token.DEFINE,
+						X:   callArgs,
+						Body: &ast.BlockStmt{
+							List: []ast.Stmt{
+								&ast.AssignStmt{
+									Lhs: []ast.Expr{&ast.IndexExpr{X: callArgs, Index: argIdx}},
+									Tok: token.ASSIGN,
+									Rhs: []ast.Expr{
+										&ast.CallExpr{
+											Fun: &ast.SelectorExpr{
+												X:   jsCallArgs,
+												Sel: &ast.Ident{Name: "Index"},
+											},
+											Args: []ast.Expr{argIdx},
+										},
+									},
+								},
+							},
+						},
+					},
+					&ast.SwitchStmt{
+						Init: &ast.AssignStmt{
+							Lhs: []ast.Expr{fnName},
+							Tok: token.DEFINE,
+							Rhs: []ast.Expr{
+								&ast.CallExpr{
+									Fun: &ast.SelectorExpr{
+										X: &ast.CallExpr{
+											Fun: &ast.SelectorExpr{
+												X:   call,
+												Sel: &ast.Ident{Name: "Get"},
+											},
+											Args: []ast.Expr{
+												&ast.BasicLit{Kind: token.STRING, Value: "\"fn\""},
+											},
+										},
+										Sel: &ast.Ident{Name: "String"},
+									},
+								},
+							},
+						},
+						Tag:  fnName,
+						Body: &ast.BlockStmt{List: cases},
+					},
+				},
+			},
+		},
+		&ast.ReturnStmt{
+			Results: []ast.Expr{results},
+		},
+	}
+
+	funcType := gen.wrapperFuncType()
+	if gen.recoversPanics() {
+		body = gen.recoverPanics(funcType, body)
+	}
+
+	return &ast.FuncDecl{
+		Name: &ast.Ident{Name: "wasmBatch"},
+		Type: funcType,
+		Body: &ast.BlockStmt{List: body},
+	}
+}
@@ -0,0 +1,397 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBatchCalls(t *testing.T) {
+	src := `package main
+
+func Add(a, b int) int {
+	return a + b
+}
+
+func Batch(n int) int {
+	return n * 2
+}
+`
+	config := NewConfig()
+	config.BatchCalls = true
+	script := `JSON.stringify(__batch([{fn: "Add", args: [1, 2]}, {fn: "Batch", args: [5]}, {fn: "Sub", args: []}]).map((result) => result instanceof Error ? result.message : result))`
+	got := runWasm(t, src, config, script)
+	if want := `[3,10,"Unknown batch function: Sub"]`; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	for _, script := range []string{`__batch()`, `__batch({fn: "Add", args: [1, 2]})`} {
+		if got := runWasmThrows(t, src, config, script); got != "Expected an array of calls" {
+			t.Errorf("Expected %s to throw Expected an array of calls, got %s", script, got)
+		}
+	}
+}
+
+func TestBatchCallErrors(t *testing.T) {
+	src := `package main
+
+import "errors"
+
+func Check(n int) (int, error) {
+	if n < 0 {
+		return 0, errors.New("negative")
+	}
+	return n, nil
+}
+`
+	config := NewConfig()
+	config.BatchCalls = true
+	config.ErrorsAsExceptions = true
+	script := `const results = __batch([{fn: "Check", args: [1]}, {fn: "Check", args: [-1]}, {fn: "Check", args: [2]}]);
+let thrown = "";
+try {
+	__batch();
+} catch (e) {
+	thrown = e.message;
+}
+JSON.stringify(results.map((result) => result instanceof Error ? "Error " + result.message : result)) + " " + thrown`
+	got := runWasm(t, src, config, script)
+	if want := `[1,"Error negative",2] Expected an array of calls`; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestAsyncStreams(t *testing.T) {
+	src := `package main
+
+type Upload struct {
+	Name string ` + "`json:\"name\"`" + `
+}
+
+func Store(u Upload) string {
+	return u.Name
+}
+
+func Double(n int) int {
+	return n * 2
+}
+`
+	config := NewConfig()
+	config.Async = true
+	// struct arguments may be ReadableStreams, decoded with encoding/json
+	got := runWasm(t, src, config, `(async () => {
+	const pending = Double(2);
+	const stream = new Blob([JSON.stringify({name: "streamed"})]).stream();
+	return [pending instanceof Promise, await pending, await Store(stream), await Store({Name: "plain"})].join(" ");
+})()`)
+	if want := "true 4 streamed plain"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestBase64Results(t *testing.T) {
+	src := `package main
+
+//wasm:base64
+func Encode(s string) []byte {
+	return []byte(s)
+}
+`
+	// lazy slices don't apply to bytes returned as base64
+	config := NewConfig()
+	config.LazySlices = true
+	got := runWasm(t, src, config, `typeof Encode("bytes") + " " + atob(Encode("bytes"))`)
+	if got != "string bytes" {
+		t.Errorf("Expected string bytes, got %s", got)
+	}
+
+	_, err := tryGenerate(`package main
+
+//wasm:base64
+func Encode(s string) string {
+	return s
+}
+`, nil)
+	if err == nil || !strings.Contains(err.Error(), "requires a single []byte result") {
+		t.Errorf("Expected an error requiring a []byte result, got %v", err)
+	}
+}
+
+func TestRawMessageResults(t *testing.T) {
+	src := `package main
+
+import "encoding/json"
+
+func Profile() json.RawMessage {
+	return json.RawMessage(` + "`" + `{"name": "a", "tags": ["b", "c"]}` + "`" + `)
+}
+`
+	got := runWasm(t, src, nil, `Profile().name + " " + Profile().tags[1]`)
+	if got != "a c" {
+		t.Errorf("Expected a c, got %s", got)
+	}
+}
+
+func TestAsyncPromiseArgs(t *testing.T) {
+	src := `package main
+
+func Add(a, b int) int {
+	return a + b
+}
+`
+	config := NewConfig()
+	config.Async = true
+	got := runWasm(t, src, config, `Add(Promise.resolve(1), 2).then(String)`)
+	if got != "3" {
+		t.Errorf("Expected 3, got %s", got)
+	}
+}
+
+func TestAsyncErrorRejects(t *testing.T) {
+	src := `package main
+
+import "errors"
+
+func Check(n int) error {
+	if n < 0 {
+		return errors.New("negative")
+	}
+	return nil
+}
+`
+	config := NewConfig()
+	config.Async = true
+	got := runWasm(t, src, config, `(async () => {
+	const results = [String(await Check(1))];
+	try {
+		await Check(-1);
+	} catch (e) {
+		results.push((e instanceof Error) + " " + e.message);
+	}
+	return results.join(",");
+})()`)
+	if got != "null,true negative" {
+		t.Errorf("Expected null,true negative, got %s", got)
+	}
+}
+
+func TestTraceCalls(t *testing.T) {
+	src := `package main
+
+import "strings"
+
+var events []string
+
+func init() {
+	OnCallStartWasm = func(name string) {
+		events = append(events, "start "+name)
+	}
+	OnCallEndWasm = func(name string, err error) {
+		events = append(events, "end "+name)
+	}
+}
+
+func Add(a, b int) int {
+	events = append(events, "add")
+	return a + b
+}
+
+func Events() string {
+	return strings.Join(events, ";")
+}
+`
+	config := NewConfig()
+	config.TraceCalls = true
+	// the hooks run around each call, including that of Events itself
+	got := runWasm(t, src, config, `Add(1, 2); Events()`)
+	if want := "start Add;add;end Add;start Events"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestEnvelopeReturns(t *testing.T) {
+	src := `package main
+
+import "errors"
+
+func Half(n int) (int, error) {
+	if n%2 != 0 {
+		return 0, errors.New("odd")
+	}
+	return n / 2, nil
+}
+
+func Name(names []string, i int) string {
+	return names[i]
+}
+`
+	config := NewConfig()
+	config.EnvelopeReturns = true
+	script := `const show = (e) => e.ok ? "ok " + e.value : "failed " + (e.error instanceof Error) + " " + e.error.message;
+[Half(4), Half(3), Name(["a"], 0), Name(["a"], 2)].map(show).join(",")`
+	got := runWasm(t, src, config, script)
+	if want := "ok 2,failed true odd,ok a,failed true runtime error: index out of range [2] with length 1"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestThrownErrorsAreCatchable(t *testing.T) {
+	src := `package main
+
+type Order struct {
+	ID int ` + "`js:\"id,required\"`" + `
+}
+
+func Place(o Order) int {
+	return o.ID
+}
+
+func Fail() int {
+	var counts map[string]int
+	counts["calls"]++
+	return counts["calls"]
+}
+`
+	script := `const results = [];
+for (const call of [() => Place({}), () => Fail(), () => Place({id: 7})]) {
+	try {
+		results.push(String(call()));
+	} catch (e) {
+		results.push(e instanceof Error ? "threw" : "threw non-error");
+	}
+}
+results.join(",")`
+	config := NewConfig()
+	config.RecoverPanics = true
+	got := runWasm(t, src, config, script)
+	if got != "threw,threw,7" {
+		t.Errorf("Expected threw,threw,7, got %s", got)
+	}
+
+	// off by default, since the throwing js functions need 'unsafe-eval' under a CSP
+	out, err := tryGenerate(src, NewConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out, "wasmThrowingFunc") {
+		t.Errorf("Expected no wasmThrowingFunc without RecoverPanics, got\n%s", out)
+	}
+}
+
+func TestResultShapes(t *testing.T) {
+	src := `package main
+
+import "errors"
+
+func Parse(ok bool) (int, error) {
+	if !ok {
+		return 0, errors.New("bad input")
+	}
+	return 42, nil
+}
+
+func Check(ok bool) error {
+	if !ok {
+		return errors.New("bad input")
+	}
+	return nil
+}
+
+func Pair(ok bool) (int, string, error) {
+	if !ok {
+		return 0, "", errors.New("bad input")
+	}
+	return 1, "one", nil
+}
+
+func Both() (int, string) {
+	return 2, "two"
+}
+`
+	script := `const show = (r) => JSON.stringify(r, r !== null && typeof r === "object" && !Array.isArray(r) ? Object.keys(r).sort() : null);
+[Parse(true), Parse(false), Check(true), Check(false), Pair(true), Pair(false), Both()].map(show).join(" ")`
+	got := runWasm(t, src, nil, script)
+	want := `{"error":null,"value":42} {"error":"bad input","value":0} null "bad input" ` +
+		`{"error":null,"value":[1,"one"]} {"error":"bad input","value":[0,""]} [2,"two"]`
+	if got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestDeclNameCollisions(t *testing.T) {
+	// wrappers of functions named after runtime helpers compile alongside them
+	src := `package main
+
+func Promise(s string) string {
+	return s
+}
+
+func ErrorValue(s string) string {
+	return s
+}
+
+func Recover(s string) string {
+	return s
+}
+
+func Envelope(n int) int {
+	return n
+}
+`
+	config := NewConfig()
+	config.Async = true
+	config.RecoverPanics = true
+	config.EnvelopeReturns = true
+	newWasmModule(t, src, config, "", nil).vet(t)
+
+	// exported hooks can't be renamed, so clashing with them is reported
+	config = NewConfig()
+	config.ExportWrappers = true
+	config.TraceCalls = true
+	_, err := tryGenerate(`package main
+
+func OnCallStart() {}
+`, config)
+	if err == nil || !strings.Contains(err.Error(), "OnCallStartWasm") {
+		t.Errorf("Expected an error naming OnCallStartWasm, got %v", err)
+	}
+}
+
+func TestErrorResults(t *testing.T) {
+	src := `package main
+
+import "errors"
+
+func Check(n int) (int, error) {
+	if n < 0 {
+		return 0, errors.New("negative")
+	}
+	return n, nil
+}
+`
+	script := `const results = [];
+for (const n of [1, -1]) {
+	try {
+		const result = Check(n);
+		results.push(typeof result === "object" ? result.value + " " + result.error : String(result));
+	} catch (e) {
+		results.push("threw " + e.message);
+	}
+}
+results.join(",")`
+	tests := []struct {
+		name   string
+		config *Config
+		want   string
+	}{
+		{"returned", &Config{}, `1 null,0 negative`},
+		{"thrown", &Config{ErrorsAsExceptions: true}, `1,threw negative`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := runWasm(t, src, test.config, script)
+			if got != test.want {
+				t.Errorf("Expected %s, got %s", test.want, got)
+			}
+		})
+	}
+}